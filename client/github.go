@@ -4,12 +4,212 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v64/github"
 )
 
+// GitHubConfig is used to pass options to NewGitHubClient. It is populated
+// by the GitHubOptions functions rather than set directly.
+type GitHubConfig struct {
+	// Token is a personal access token (or any other bearer token) used to
+	// authenticate against the GitHub api.
+	Token string
+	// AppID is the ID of a GitHub App, used along with InstallationID and
+	// PrivateKey to authenticate as an App installation.
+	AppID int64
+	// InstallationID is the ID of the GitHub App installation to request tokens for.
+	InstallationID int64
+	// PrivateKey is the PEM encoded private key of the GitHub App.
+	PrivateKey []byte
+	// BaseURL is an optional GitHub Enterprise endpoint, either the server, e.g.
+	// "https://<hostname>/", or its api, "https://<hostname>/api/v3/". Uploads go to the
+	// server's "api/uploads/". When empty the public https://api.github.com/ endpoint is used.
+	BaseURL string
+	// HTTPClient is an optional http client to build the GitHub client on top of.
+	HTTPClient *http.Client
+	// Verify makes NewGitHubClient call the api once to confirm the credentials work.
+	Verify bool
+	// MaxRetries is the number of times a rate limited or failed request is retried.
+	// It defaults to 3, set it to 0 to disable retries.
+	MaxRetries int
+	// MaxRetryWait is the longest to wait before a single retry. It defaults to one minute.
+	MaxRetryWait time.Duration
+	// DisableCache turns off the conditional request (ETag) cache for GET requests.
+	DisableCache bool
+}
+
+// GitHubOptions is a function type used to modify the GitHubConfig.
+type GitHubOptions func(*GitHubConfig)
+
+// WithToken returns a GitHubOptions function that authenticates using a personal access token.
+func WithToken(token string) GitHubOptions {
+	return func(c *GitHubConfig) {
+		c.Token = token
+	}
+}
+
+// WithAppInstallation returns a GitHubOptions function that authenticates as a GitHub App installation.
+// Installation tokens are requested on first use and refreshed before they expire.
+func WithAppInstallation(appID, installationID int64, privateKey []byte) GitHubOptions {
+	return func(c *GitHubConfig) {
+		c.AppID = appID
+		c.InstallationID = installationID
+		c.PrivateKey = privateKey
+	}
+}
+
+// WithBaseURL returns a GitHubOptions function that points the client at a GitHub Enterprise
+// server, given as "https://<hostname>/" or "https://<hostname>/api/v3/".
+func WithBaseURL(baseURL string) GitHubOptions {
+	return func(c *GitHubConfig) {
+		c.BaseURL = baseURL
+	}
+}
+
+// WithHTTPClient returns a GitHubOptions function that sets the http client used to make requests.
+func WithHTTPClient(httpClient *http.Client) GitHubOptions {
+	return func(c *GitHubConfig) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithVerification returns a GitHubOptions function that makes NewGitHubClient check the
+// credentials against the api before returning. The rate limit endpoint is used for this
+// as it works for both tokens and App installations and doesn't count against the quota.
+func WithVerification() GitHubOptions {
+	return func(c *GitHubConfig) {
+		c.Verify = true
+	}
+}
+
+// WithRetries returns a GitHubOptions function that sets how many times a rate limited
+// or failed request is retried, and the longest to wait before each retry.
+func WithRetries(maxRetries int, maxWait time.Duration) GitHubOptions {
+	return func(c *GitHubConfig) {
+		c.MaxRetries = maxRetries
+		c.MaxRetryWait = maxWait
+	}
+}
+
+// WithoutCache returns a GitHubOptions function that disables the conditional request cache.
+func WithoutCache() GitHubOptions {
+	return func(c *GitHubConfig) {
+		c.DisableCache = true
+	}
+}
+
+// NewGitHubClient takes a number of GitHubOptions and returns an authenticated GitHub client.
+// Exactly one of WithToken or WithAppInstallation must be passed.
+//
+// Requests made by the client respect the GitHub rate limit headers, are retried on
+// secondary rate limits and transient server errors, and GET responses are cached so
+// repeated calls are sent as conditional requests that don't use up the quota.
+func NewGitHubClient(ctx context.Context, opts ...GitHubOptions) (*github.Client, error) {
+	config := &GitHubConfig{
+		MaxRetries:   defaultMaxRetries,
+		MaxRetryWait: defaultMaxRetryWait,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	httpClient, err := config.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newClient(httpClient, config.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Verify {
+		if _, _, err := client.RateLimit.Get(ctx); err != nil {
+			return nil, fmt.Errorf("failed to verify github credentials: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// httpClient builds the authenticated http client described by the config.
+func (c *GitHubConfig) httpClient() (*http.Client, error) {
+	useToken := c.Token != ""
+	useApp := c.AppID != 0 || c.InstallationID != 0 || len(c.PrivateKey) > 0
+
+	switch {
+	case useToken && useApp:
+		return nil, fmt.Errorf("only one of a token or a GitHub App installation can be set")
+	case !useToken && !useApp:
+		return nil, fmt.Errorf("a token or a GitHub App installation must be set")
+	}
+
+	base := http.DefaultTransport
+	if c.HTTPClient != nil && c.HTTPClient.Transport != nil {
+		base = c.HTTPClient.Transport
+	}
+	base = newRateLimitTransport(base, c.MaxRetries, c.MaxRetryWait, !c.DisableCache)
+
+	var transport http.RoundTripper
+	if useToken {
+		transport = &tokenTransport{token: c.Token, base: base}
+	} else {
+		if c.AppID == 0 || c.InstallationID == 0 || len(c.PrivateKey) == 0 {
+			return nil, fmt.Errorf("app id, installation id and private key must all be set")
+		}
+		key, err := parsePrivateKey(c.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		transport = &installationTransport{
+			appID:          c.AppID,
+			installationID: c.InstallationID,
+			key:            key,
+			baseURL:        c.BaseURL,
+			base:           base,
+		}
+	}
+
+	httpClient := &http.Client{Transport: transport}
+	if c.HTTPClient != nil {
+		httpClient.Timeout = c.HTTPClient.Timeout
+		httpClient.Jar = c.HTTPClient.Jar
+		httpClient.CheckRedirect = c.HTTPClient.CheckRedirect
+	}
+
+	return httpClient, nil
+}
+
+// newClient returns a GitHub client for the public api, or for a GitHub Enterprise server when
+// baseURL is set. go-github adds the "api/v3/" and "api/uploads/" paths to the server's url,
+// so the upload url is the server's, with any "api/v3" removed.
+func newClient(httpClient *http.Client, baseURL string) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if baseURL == "" {
+		return client, nil
+	}
+
+	uploadURL := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v3")
+	client, err := client.WithEnterpriseURLs(baseURL, uploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github base url %q: %w", baseURL, err)
+	}
+	return client, nil
+}
+
+// GitHubClient takes a personal access token and returns a verified GitHub client,
+// logging the current rate limit.
+//
+// Deprecated: Use NewGitHubClient, which returns an error instead of nil on failure.
 func GitHubClient(token string, ctx context.Context) *github.Client {
-	client := github.NewClient(nil).WithAuthToken(token)
+	client, err := NewGitHubClient(ctx, WithToken(token))
+	if err != nil {
+		fmt.Printf("\nerror: %v\n", err)
+		return nil
+	}
 
 	_, resp, err := client.Users.Get(ctx, "")
 	if err != nil {
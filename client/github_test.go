@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newGitHubServer returns a fake GitHub Enterprise server whose api only answers the rate
+// limit and installation token endpoints, recording the Authorization header it receives.
+func newGitHubServer(t *testing.T, auth *[]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		*auth = append(*auth, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer bad" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		w.Write([]byte(`{"resources":{"core":{"limit":5000,"remaining":5000}}}`))
+	})
+	mux.HandleFunc("/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		*auth = append(*auth, r.Header.Get("Authorization"))
+		expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token":"installation-token","expires_at":"` + expires + `"}`))
+	})
+	server := httptest.NewServer(http.StripPrefix("/api/v3", mux))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewGitHubClient(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tests := []struct {
+		name       string
+		opts       []GitHubOptions
+		wantAuth   []string
+		wantPrefix bool
+		wantErr    bool
+	}{
+		{
+			name:     "token with verification",
+			opts:     []GitHubOptions{WithToken("good"), WithVerification()},
+			wantAuth: []string{"Bearer good"},
+		},
+		{
+			name:    "bad token with verification",
+			opts:    []GitHubOptions{WithToken("bad"), WithVerification()},
+			wantErr: true,
+		},
+		{
+			name:     "token without verification makes no calls",
+			opts:     []GitHubOptions{WithToken("bad")},
+			wantAuth: nil,
+		},
+		{
+			name:       "app installation with verification",
+			opts:       []GitHubOptions{WithAppInstallation(1, 42, pemKey), WithVerification()},
+			wantAuth:   []string{"Bearer ", "token installation-token"},
+			wantPrefix: true,
+		},
+		{
+			name:    "no credentials",
+			opts:    []GitHubOptions{WithVerification()},
+			wantErr: true,
+		},
+		{
+			name:    "token and app installation",
+			opts:    []GitHubOptions{WithToken("good"), WithAppInstallation(1, 42, pemKey)},
+			wantErr: true,
+		},
+		{
+			name:    "app installation with invalid key",
+			opts:    []GitHubOptions{WithAppInstallation(1, 42, []byte("not a key"))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var auth []string
+			server := newGitHubServer(t, &auth)
+
+			opts := append([]GitHubOptions{WithBaseURL(server.URL)}, tt.opts...)
+			got, err := NewGitHubClient(context.Background(), opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewGitHubClient() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.BaseURL.String() != server.URL+"/api/v3/" || got.UploadURL.String() != server.URL+"/api/uploads/" {
+				t.Errorf("NewGitHubClient() BaseURL = %v, UploadURL = %v, want the server's api and uploads", got.BaseURL, got.UploadURL)
+			}
+			if len(auth) != len(tt.wantAuth) {
+				t.Fatalf("NewGitHubClient() auth headers = %v, want %v", auth, tt.wantAuth)
+			}
+			for i := range auth {
+				if tt.wantPrefix && !strings.HasPrefix(auth[i], tt.wantAuth[i]) || !tt.wantPrefix && auth[i] != tt.wantAuth[i] {
+					t.Errorf("NewGitHubClient() auth header %d = %v, want %v", i, auth[i], tt.wantAuth[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewGitHubClientEnterpriseURLs(t *testing.T) {
+	for _, baseURL := range []string{
+		"https://github.example.com",
+		"https://github.example.com/",
+		"https://github.example.com/api/v3",
+		"https://github.example.com/api/v3/",
+	} {
+		got, err := NewGitHubClient(context.Background(), WithToken("good"), WithBaseURL(baseURL))
+		if err != nil {
+			t.Fatalf("NewGitHubClient(%s) error = %v", baseURL, err)
+		}
+		if got.BaseURL.String() != "https://github.example.com/api/v3/" || got.UploadURL.String() != "https://github.example.com/api/uploads/" {
+			t.Errorf("NewGitHubClient(%s) BaseURL = %v, UploadURL = %v", baseURL, got.BaseURL, got.UploadURL)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxRetries is the number of times a request is retried after a
+	// transient failure or rate limit response.
+	defaultMaxRetries = 3
+	// defaultMaxRetryWait is the longest the transport will wait before a retry.
+	// Rate limits that reset further in the future are returned to the caller instead.
+	defaultMaxRetryWait = time.Minute
+	// defaultCacheSize is the number of GET responses kept for conditional requests.
+	defaultCacheSize = 500
+	// defaultCacheBytes is the most response body bytes the cache holds in total.
+	defaultCacheBytes = 32 << 20
+	// maxCachedBody is the largest response body cached. Larger responses, such as big
+	// trees or archives, are passed through without being held in memory.
+	maxCachedBody = 1 << 20
+	// baseBackoff is the first wait used when the api gives no hint of its own.
+	baseBackoff = time.Second
+)
+
+// rateLimitTransport wraps a http.RoundTripper so that it:
+//   - waits for the primary rate limit to reset when it is known to be exhausted, tracking
+//     each of the api's rate limits, e.g. core, search and graphql, separately,
+//   - retries secondary (abuse) rate limits using Retry-After or X-RateLimit-Reset,
+//   - retries 5xx responses to idempotent requests with exponential backoff,
+//   - sends If-None-Match for previously seen GET responses and replays the cached
+//     body on a 304, as conditional requests don't count against the rate limit.
+type rateLimitTransport struct {
+	base         http.RoundTripper
+	maxRetries   int
+	maxRetryWait time.Duration
+	cache        *etagCache
+	// sleep is replaced in tests to avoid waiting.
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+
+	mu     sync.Mutex
+	limits map[string]rateLimit
+}
+
+// rateLimit is the last seen state of one of the api's primary rate limits.
+type rateLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+func newRateLimitTransport(base http.RoundTripper, maxRetries int, maxRetryWait time.Duration, cache bool) *rateLimitTransport {
+	t := &rateLimitTransport{
+		base:         base,
+		maxRetries:   maxRetries,
+		maxRetryWait: maxRetryWait,
+		sleep:        sleepContext,
+		now:          time.Now,
+		limits:       make(map[string]rateLimit),
+	}
+	if cache {
+		t.cache = newETagCache(defaultCacheSize, defaultCacheBytes)
+	}
+	return t
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.waitForReset(req.Context(), requestResource(req)); err != nil {
+		return nil, err
+	}
+
+	key := cacheKey(req)
+	cached := t.cache.get(key)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.recordRateLimit(req, resp)
+
+		wait, retry := t.retryAfter(req, resp, attempt)
+		if !retry {
+			return t.handleCache(key, cached, resp)
+		}
+
+		next, err := rewindBody(req)
+		if err != nil {
+			return t.handleCache(key, cached, resp)
+		}
+		drainBody(resp)
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		req = next
+	}
+}
+
+// waitForReset blocks until a primary rate limit resets, if the last response counted
+// against it showed it was exhausted and the reset is within the maximum wait.
+func (t *rateLimitTransport) waitForReset(ctx context.Context, resource string) error {
+	t.mu.Lock()
+	limit, ok := t.limits[resource]
+	t.mu.Unlock()
+
+	if !ok || limit.remaining != 0 {
+		return nil
+	}
+
+	wait := limit.reset.Sub(t.now())
+	if wait <= 0 || wait > t.maxRetryWait {
+		return nil
+	}
+
+	return t.sleep(ctx, wait)
+}
+
+// recordRateLimit stores the primary rate limit headers from a response, under the rate
+// limit named by X-RateLimit-Resource.
+func (t *rateLimitTransport) recordRateLimit(req *http.Request, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = requestResource(req)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[resource] = rateLimit{remaining: remaining, reset: time.Unix(reset, 0)}
+}
+
+// requestResource returns the rate limit a request counts against, named as in
+// X-RateLimit-Resource. It is worked out from the path as the header is only known once
+// the response arrives.
+func requestResource(req *http.Request) string {
+	p := req.URL.Path
+	switch {
+	case strings.HasSuffix(p, "/graphql"):
+		return "graphql"
+	case strings.Contains(p, "/search/code"):
+		return "code_search"
+	case strings.Contains(p, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// retryAfter decides whether a response should be retried and how long to wait first.
+func (t *rateLimitTransport) retryAfter(req *http.Request, resp *http.Response, attempt int) (time.Duration, bool) {
+	if attempt >= t.maxRetries {
+		return 0, false
+	}
+
+	switch {
+	case isRateLimited(resp):
+		wait, ok := t.rateLimitWait(resp)
+		if !ok {
+			wait = backoff(attempt)
+		}
+		return wait, wait <= t.maxRetryWait
+	case resp.StatusCode >= http.StatusInternalServerError && isIdempotent(req.Method):
+		wait := backoff(attempt)
+		return wait, wait <= t.maxRetryWait
+	default:
+		return 0, false
+	}
+}
+
+// rateLimitWait reads the wait the api asked for from Retry-After or X-RateLimit-Reset.
+func (t *rateLimitTransport) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if date, err := http.ParseTime(v); err == nil {
+			return max(date.Sub(t.now()), 0), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(t.now()), 0), true
+		}
+	}
+
+	return 0, false
+}
+
+// handleCache stores successful GET responses carrying an ETag, and swaps a
+// 304 Not Modified for the cached response so callers see a normal 200.
+func (t *rateLimitTransport) handleCache(key string, cached *cachedResponse, resp *http.Response) (*http.Response, error) {
+	if t.cache == nil || key == "" {
+		return resp, nil
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		drainBody(resp)
+		header := cached.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       resp.Request,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" || resp.ContentLength > maxCachedBody {
+		return resp, nil
+	}
+
+	// The body is read up to one byte over the limit, to find out whether it fits.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedBody {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.add(key, &cachedResponse{etag: etag, header: resp.Header.Clone(), body: body})
+
+	return resp, nil
+}
+
+// isRateLimited reports whether a response is a primary or secondary rate limit rejection.
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+	return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// isIdempotent reports whether a request can safely be sent twice.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns an exponential wait with jitter for the given attempt.
+func backoff(attempt int) time.Duration {
+	wait := baseBackoff << attempt
+	return wait + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// rewindBody returns a copy of the request with a fresh body so it can be sent again.
+func rewindBody(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+	if req.GetBody == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return next, nil
+}
+
+// drainBody reads and closes a response body so the connection can be reused.
+func drainBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// sleepContext waits for the duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cachedResponse is a GET response kept for replaying after a 304 Not Modified.
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// etagCache is a small least recently used cache of GET responses, limited by both the
+// number of responses and their total body size. A nil *etagCache is valid and caches nothing.
+type etagCache struct {
+	mu       sync.Mutex
+	size     int
+	maxBytes int
+	bytes    int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type etagEntry struct {
+	key      string
+	response *cachedResponse
+}
+
+func newETagCache(size, maxBytes int) *etagCache {
+	return &etagCache{
+		size:     size,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *etagCache) get(key string) *cachedResponse {
+	if c == nil || key == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*etagEntry).response
+}
+
+func (c *etagCache) add(key string, response *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(response.body) > c.maxBytes {
+		return
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*etagEntry)
+		c.bytes += len(response.body) - len(entry.response.body)
+		entry.response = response
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.order.PushFront(&etagEntry{key: key, response: response})
+		c.bytes += len(response.body)
+	}
+
+	for c.order.Len() > c.size || c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		entry := oldest.Value.(*etagEntry)
+		delete(c.entries, entry.key)
+		c.bytes -= len(entry.response.body)
+	}
+}
+
+// cacheKey identifies a cacheable request. Only GET requests are cached, and the
+// Accept header is included as the same url can return different media types.
+func cacheKey(req *http.Request) string {
+	if req.Method != http.MethodGet {
+		return ""
+	}
+	return req.URL.String() + " " + req.Header.Get("Accept")
+}
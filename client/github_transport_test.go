@@ -0,0 +1,280 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedServer replies to each request with the next status and headers in its script.
+type scriptedServer struct {
+	responses []scriptedResponse
+	requests  []*http.Request
+}
+
+type scriptedResponse struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r)
+	resp := s.responses[len(s.requests)-1]
+	for k, v := range resp.header {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(resp.status)
+	w.Write([]byte(resp.body))
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		responses []scriptedResponse
+		wantCalls int
+		wantWaits []time.Duration
+		wantCode  int
+	}{
+		{
+			name:   "retries server errors on GET",
+			method: http.MethodGet,
+			responses: []scriptedResponse{
+				{status: http.StatusBadGateway},
+				{status: http.StatusOK, body: "ok"},
+			},
+			wantCalls: 2,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:   "does not retry server errors on POST",
+			method: http.MethodPost,
+			responses: []scriptedResponse{
+				{status: http.StatusInternalServerError},
+			},
+			wantCalls: 1,
+			wantCode:  http.StatusInternalServerError,
+		},
+		{
+			name:   "waits for Retry-After on secondary rate limit",
+			method: http.MethodPost,
+			responses: []scriptedResponse{
+				{status: http.StatusForbidden, header: map[string]string{"Retry-After": "30"}},
+				{status: http.StatusCreated},
+			},
+			wantCalls: 2,
+			wantWaits: []time.Duration{30 * time.Second},
+			wantCode:  http.StatusCreated,
+		},
+		{
+			name:   "returns rate limit that resets beyond the maximum wait",
+			method: http.MethodGet,
+			responses: []scriptedResponse{
+				{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "3600"}},
+			},
+			wantCalls: 1,
+			wantCode:  http.StatusTooManyRequests,
+		},
+		{
+			name:   "gives up after the maximum retries",
+			method: http.MethodGet,
+			responses: []scriptedResponse{
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+			},
+			wantCalls: 4,
+			wantCode:  http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &scriptedServer{responses: tt.responses}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			var waits []time.Duration
+			transport := newRateLimitTransport(http.DefaultTransport, defaultMaxRetries, defaultMaxRetryWait, true)
+			transport.sleep = func(_ context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			req, _ := http.NewRequest(tt.method, ts.URL, strings.NewReader("body"))
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("RoundTrip() status = %v, want %v", resp.StatusCode, tt.wantCode)
+			}
+			if len(server.requests) != tt.wantCalls {
+				t.Errorf("RoundTrip() calls = %v, want %v", len(server.requests), tt.wantCalls)
+			}
+			for i, want := range tt.wantWaits {
+				if waits[i] != want {
+					t.Errorf("RoundTrip() wait %d = %v, want %v", i, waits[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitTransportConditionalRequests(t *testing.T) {
+	server := &scriptedServer{responses: []scriptedResponse{
+		{status: http.StatusOK, header: map[string]string{"ETag": `"abc"`}, body: "content"},
+		{status: http.StatusNotModified},
+	}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	transport := newRateLimitTransport(http.DefaultTransport, defaultMaxRetries, defaultMaxRetryWait, true)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/contents/main.tf", nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip() error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || string(body) != "content" {
+			t.Errorf("RoundTrip() %d = %v %q, want 200 %q", i, resp.StatusCode, body, "content")
+		}
+	}
+
+	if got := server.requests[1].Header.Get("If-None-Match"); got != `"abc"` {
+		t.Errorf("second request If-None-Match = %q, want %q", got, `"abc"`)
+	}
+}
+
+func TestRateLimitTransportWaitsForReset(t *testing.T) {
+	reset := time.Now().Add(10 * time.Second).Unix()
+	server := &scriptedServer{responses: []scriptedResponse{
+		{status: http.StatusOK, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset, 10)}},
+		{status: http.StatusOK},
+	}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := newRateLimitTransport(http.DefaultTransport, defaultMaxRetries, defaultMaxRetryWait, false)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return sleepContext(ctx, d)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("first RoundTrip() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := transport.RoundTrip(req); err != context.Canceled {
+		t.Errorf("second RoundTrip() error = %v, want %v", err, context.Canceled)
+	}
+	if len(server.requests) != 1 {
+		t.Errorf("RoundTrip() calls = %v, want 1", len(server.requests))
+	}
+}
+
+func TestRateLimitTransportTracksEachResource(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(10*time.Second).Unix(), 10)
+	server := &scriptedServer{responses: []scriptedResponse{
+		{status: http.StatusOK, header: map[string]string{"X-RateLimit-Resource": "search", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}},
+		{status: http.StatusOK},
+	}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	transport := newRateLimitTransport(http.DefaultTransport, defaultMaxRetries, defaultMaxRetryWait, false)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		t.Errorf("RoundTrip() waited %v for the search rate limit on a core request", d)
+		return nil
+	}
+
+	for _, path := range []string{"/search/issues", "/repos/o/r"} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip(%s) error = %v", path, err)
+		}
+		resp.Body.Close()
+	}
+	if len(server.requests) != 2 {
+		t.Errorf("RoundTrip() calls = %v, want 2", len(server.requests))
+	}
+}
+
+func TestRequestResource(t *testing.T) {
+	for path, want := range map[string]string{
+		"/repos/o/r":       "core",
+		"/search/issues":   "search",
+		"/search/code":     "code_search",
+		"/graphql":         "graphql",
+		"/api/v3/graphql":  "graphql",
+		"/api/v3/search/x": "search",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.github.com"+path, nil)
+		if got := requestResource(req); got != want {
+			t.Errorf("requestResource(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRateLimitTransportSkipsCachingLargeBodies(t *testing.T) {
+	large := strings.Repeat("x", maxCachedBody+1)
+	server := &scriptedServer{responses: []scriptedResponse{
+		{status: http.StatusOK, header: map[string]string{"ETag": `"abc"`}, body: large},
+		{status: http.StatusOK, header: map[string]string{"ETag": `"abc"`}, body: large},
+	}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	transport := newRateLimitTransport(http.DefaultTransport, defaultMaxRetries, defaultMaxRetryWait, true)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/git/trees/main", nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip() error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if len(body) != len(large) {
+			t.Errorf("RoundTrip() %d body = %d bytes, want %d", i, len(body), len(large))
+		}
+	}
+
+	if got := server.requests[1].Header.Get("If-None-Match"); got != "" {
+		t.Errorf("second request If-None-Match = %q, want none for an uncached body", got)
+	}
+}
+
+func TestETagCacheEvictsBySize(t *testing.T) {
+	cache := newETagCache(10, 10)
+	cache.add("a", &cachedResponse{body: []byte("aaaa")})
+	cache.add("b", &cachedResponse{body: []byte("bbbb")})
+	cache.add("c", &cachedResponse{body: []byte("cccc")})
+	cache.add("d", &cachedResponse{body: []byte("too large to cache")})
+
+	for key, want := range map[string]bool{"a": false, "b": true, "c": true, "d": false} {
+		if _, ok := cache.entries[key]; ok != want {
+			t.Errorf("etagCache has %q = %v, want %v", key, ok, want)
+		}
+	}
+	if cache.bytes != 8 {
+		t.Errorf("etagCache bytes = %d, want 8", cache.bytes)
+	}
+}
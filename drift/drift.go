@@ -0,0 +1,125 @@
+// Package drift reports the terraform modules in the namespaces of the Cloud Platform
+// environments repository that are pinned behind the latest release of their repository.
+package drift
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	gogithub "github.com/google/go-github/v64/github"
+	"github.com/ministryofjustice/cloud-platform-go-library/github"
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
+)
+
+// ModuleUsage is a module block in a namespace's terraform.
+type ModuleUsage struct {
+	Cluster   string
+	Namespace string
+	// Team is the namespace's team name annotation, or "" if it has none.
+	Team string
+	// Filename is the path of the terraform file from the root of the repository.
+	Filename string
+	Module   *terraform.Module
+	Source   *terraform.ModuleSource
+}
+
+// ModuleDrift is a module pinned to an older version than the latest of its repository.
+type ModuleDrift struct {
+	Usage   *ModuleUsage
+	Current terraform.Version
+	Latest  terraform.Version
+}
+
+// MajorBehind reports whether the module is a major version behind.
+func (d *ModuleDrift) MajorBehind() bool {
+	return d.Current.Major < d.Latest.Major
+}
+
+// Report is the modules in each namespace that are behind the latest version.
+type Report struct {
+	// Teams maps a team name to its modules that are behind, ordered by filename.
+	// Modules in namespaces without a team name annotation are under "".
+	Teams map[string][]*ModuleDrift
+	// Latest is the latest version of each module repository, keyed by org/repo.
+	Latest map[string]terraform.Version
+	// Unpinned are GitHub modules whose ref isn't a version, e.g. a branch, or that have no ref.
+	Unpinned []*ModuleUsage
+}
+
+// TeamNames returns the teams with modules that are behind, in order.
+func (r *Report) TeamNames() []string {
+	teams := make([]string, 0, len(r.Teams))
+	for team := range r.Teams {
+		teams = append(teams, team)
+	}
+	slices.Sort(teams)
+	return teams
+}
+
+// ModuleDriftReport fetches the latest version of each GitHub module repository used and
+// reports the modules behind it. Repositories that can't be found, such as private ones
+// the client can't see, and repositories without version tags are left out.
+func ModuleDriftReport(ctx context.Context, client *gogithub.Client, usages []*ModuleUsage) (*Report, error) {
+	latest := make(map[string]terraform.Version)
+	checked := make(map[string]bool)
+
+	for _, u := range usages {
+		if u.Source.Kind != terraform.SourceGit || u.Source.Host != "github.com" {
+			continue
+		}
+		repository := u.Source.Repository()
+		if checked[repository] {
+			continue
+		}
+		checked[repository] = true
+
+		v, ok, err := github.LatestVersion(ctx, client, u.Source.Org, u.Source.Repo)
+		var errResp *gogithub.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			latest[repository] = v
+		}
+	}
+
+	return NewReport(usages, latest), nil
+}
+
+// NewReport compares each GitHub module's ref with the latest version of its
+// repository, keyed by org/repo, and groups the modules that are behind by team.
+func NewReport(usages []*ModuleUsage, latest map[string]terraform.Version) *Report {
+	report := &Report{
+		Teams:  make(map[string][]*ModuleDrift),
+		Latest: latest,
+	}
+
+	for _, u := range usages {
+		if u.Source.Kind != terraform.SourceGit || u.Source.Host != "github.com" {
+			continue
+		}
+		latestVersion, ok := latest[u.Source.Repository()]
+		if !ok {
+			continue
+		}
+		current, err := u.Source.Version()
+		if err != nil {
+			report.Unpinned = append(report.Unpinned, u)
+			continue
+		}
+		if current.LessThan(latestVersion) {
+			report.Teams[u.Team] = append(report.Teams[u.Team], &ModuleDrift{
+				Usage:   u,
+				Current: current,
+				Latest:  latestVersion,
+			})
+		}
+	}
+
+	return report
+}
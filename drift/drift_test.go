@@ -0,0 +1,175 @@
+package drift
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ministryofjustice/cloud-platform-go-library/internal/githubtest"
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
+)
+
+const driftNamespace = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+  annotations:
+    cloud-platform.justice.gov.uk/team-name: %s
+`
+
+const driftModules = `module "ecr" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=%s"
+}
+
+module "local" {
+  source = "../modules/app"
+}
+
+module "branch" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=main"
+}
+`
+
+func TestScanNamespacesDir(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"namespaces/live/live.cloud-platform.service.justice.gov.uk/ns-a/00-namespace.yaml":  fmt.Sprintf(driftNamespace, "ns-a", "team-a"),
+		"namespaces/live/live.cloud-platform.service.justice.gov.uk/ns-a/resources/ecr.tf":   fmt.Sprintf(driftModules, "6.0.0"),
+		"namespaces/live/live.cloud-platform.service.justice.gov.uk/ns-b/00-namespace.yaml":  fmt.Sprintf(driftNamespace, "ns-b", "team-b"),
+		"namespaces/live/live.cloud-platform.service.justice.gov.uk/ns-b/resources/ecr.tf":   fmt.Sprintf(driftModules, "6.1.0"),
+		"namespaces/live/live.cloud-platform.service.justice.gov.uk/ns-b/resources/bad.tf":   `module "broken" {`,
+		"namespaces/live/live.cloud-platform.service.justice.gov.uk/ns-b/resources/notes.md": "not terraform",
+		"namespaces/live/live.cloud-platform.service.justice.gov.uk/ns-c/00-namespace.yaml":  "kind: Pod",
+		"README.md": "not a namespace",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usages, diags, err := ScanNamespacesDir(root)
+	if err != nil {
+		t.Fatalf("ScanNamespacesDir() error = %v", err)
+	}
+	// The broken file and manifest are reported without stopping the scan.
+	files := make(map[string]bool)
+	for _, diag := range diags {
+		files[filepath.Base(diag.Subject.Filename)] = true
+	}
+	if !diags.HasErrors() || !files["bad.tf"] || !files["00-namespace.yaml"] {
+		t.Errorf("ScanNamespacesDir() diagnostics = %v, want errors for bad.tf and the ns-c manifest", diags)
+	}
+	if len(usages) != 6 {
+		t.Fatalf("ScanNamespacesDir() = %d usages, want 6", len(usages))
+	}
+	if u := usages[0]; u.Namespace != "ns-a" || u.Team != "team-a" || u.Module.Name != "ecr" || u.Source.Ref != "6.0.0" {
+		t.Errorf("ScanNamespacesDir()[0] = %+v", u)
+	}
+
+	report := NewReport(usages, map[string]terraform.Version{
+		"ministryofjustice/cloud-platform-terraform-ecr-credentials": {Major: 6, Minor: 1},
+		"ministryofjustice/cloud-platform-terraform-sqs":             {Major: 5},
+	})
+	if teams := report.TeamNames(); len(teams) != 1 || teams[0] != "team-a" {
+		t.Fatalf("Report.TeamNames() = %v, want [team-a]", teams)
+	}
+	drift := report.Teams["team-a"]
+	if len(drift) != 1 || drift[0].Current.String() != "6.0.0" || drift[0].Latest.String() != "6.1.0" || drift[0].MajorBehind() {
+		t.Errorf("Report.Teams[team-a] = %+v", drift)
+	}
+	if len(report.Unpinned) != 2 {
+		t.Errorf("Report.Unpinned = %d usages, want 2", len(report.Unpinned))
+	}
+}
+
+// tarball builds a gzipped tarball laid out like a GitHub archive, with every file under
+// a top-level directory.
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: "o-r-abc123/" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestScanNamespacesRepoAndModuleDriftReport(t *testing.T) {
+	client, mux, serverURL := githubtest.Setup(t)
+
+	archive := tarball(t, map[string]string{
+		"namespaces/live/cluster/ns-a/00-namespace.yaml":        fmt.Sprintf(driftNamespace, "ns-a", "team-a"),
+		"namespaces/live/cluster/ns-a/resources/ecr.tf":         fmt.Sprintf(driftModules, "5.0.0"),
+		"namespaces/live/cluster/ns-a/../../../../../escape.tf": `module "x" {}`,
+		"README.md": "not a namespace",
+	})
+
+	requests := 0
+	mux.HandleFunc("/repos/o/r/tarball/main", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Redirect(w, r, serverURL+"/archive/o-r-abc123.tar.gz", http.StatusFound)
+	})
+	mux.HandleFunc("/archive/o-r-abc123.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(archive)
+	})
+	mux.HandleFunc("/repos/ministryofjustice/cloud-platform-terraform-ecr-credentials/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"7.0.0-rc.1"},{"name":"6.10.0"},{"name":"latest"},{"name":"6.2.0"}]`)
+	})
+	mux.HandleFunc("/repos/ministryofjustice/cloud-platform-terraform-sqs/tags", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+
+	ctx := context.Background()
+	usages, diags, err := ScanNamespacesRepo(ctx, client, "o", "r", "main")
+	if err != nil {
+		t.Fatalf("ScanNamespacesRepo() error = %v", err)
+	}
+	if diags.HasErrors() {
+		t.Errorf("ScanNamespacesRepo() diagnostics = %v", diags)
+	}
+	if requests != 2 {
+		t.Errorf("ScanNamespacesRepo() made %d requests, want 2 for the archive", requests)
+	}
+	if len(usages) != 3 || usages[0].Team != "team-a" || usages[0].Filename != "namespaces/live/cluster/ns-a/resources/ecr.tf" {
+		t.Fatalf("ScanNamespacesRepo() = %+v", usages)
+	}
+
+	report, err := ModuleDriftReport(ctx, client, usages)
+	if err != nil {
+		t.Fatalf("ModuleDriftReport() error = %v", err)
+	}
+	if v := report.Latest["ministryofjustice/cloud-platform-terraform-ecr-credentials"]; v.String() != "6.10.0" {
+		t.Errorf("ModuleDriftReport() latest = %v, want 6.10.0", v)
+	}
+	drift := report.Teams["team-a"]
+	if len(drift) != 1 || !drift[0].MajorBehind() {
+		t.Errorf("ModuleDriftReport() Teams[team-a] = %+v", drift)
+	}
+	if len(report.Unpinned) != 0 {
+		t.Errorf("ModuleDriftReport() Unpinned = %v, want none as sqs was not found", report.Unpinned)
+	}
+}
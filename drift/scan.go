@@ -0,0 +1,198 @@
+package drift
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	gogithub "github.com/google/go-github/v64/github"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/ministryofjustice/cloud-platform-go-library/github"
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
+)
+
+// ScanNamespacesDir parses the terraform in every namespace of a checked out environments
+// repository, where root is the root of the repository, and returns each module block
+// with a parseable source. Files that can't be parsed are reported in the diagnostics and
+// the scan carries on; the error is only for failing to read the repository.
+func ScanNamespacesDir(root string) ([]*ModuleUsage, hcl.Diagnostics, error) {
+	files := make(map[string][]byte)
+
+	err := filepath.WalkDir(filepath.Join(root, github.NamespacesDir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !scannedFile(rel) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[rel] = content
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading namespaces: %w", err)
+	}
+
+	usages, diags := scanNamespaceFiles(files)
+	return usages, diags, nil
+}
+
+// ScanNamespacesRepo is ScanNamespacesDir for an environments repository on GitHub at a ref.
+// The repository is downloaded as one archive, rather than a request per file, and the
+// namespaces in it are extracted to a temporary directory to be scanned.
+func ScanNamespacesRepo(ctx context.Context, client *gogithub.Client, owner, repo, ref string) ([]*ModuleUsage, hcl.Diagnostics, error) {
+	archive, err := github.DownloadArchive(ctx, client, owner, repo, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer archive.Close()
+
+	dir, err := os.MkdirTemp("", "namespaces")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractNamespaces(archive, dir); err != nil {
+		return nil, nil, fmt.Errorf("error extracting archive of %s/%s at %s: %w", owner, repo, ref, err)
+	}
+
+	return ScanNamespacesDir(dir)
+}
+
+// extractNamespaces writes the files of a gzipped repository tarball that are needed to scan
+// its namespaces to dir, dropping the archive's top-level directory.
+func extractNamespaces(archive io.Reader, dir string) error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		_, name, ok := strings.Cut(header.Name, "/")
+		// scannedFile only accepts cleaned paths below github.NamespacesDir, so nothing is
+		// written outside dir.
+		if name = path.Clean(name); !ok || !scannedFile(name) {
+			continue
+		}
+
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+}
+
+// scannedFile reports whether a file is needed to scan a namespace: its terraform
+// files and its namespace manifest.
+func scannedFile(filename string) bool {
+	cluster, ns, ok := github.ParseNamespacePath(filename)
+	if !ok {
+		return false
+	}
+	return path.Ext(filename) == ".tf" || filename == github.NamespaceManifestPath(cluster, ns)
+}
+
+// scanNamespaceFiles parses the terraform files, keyed by path from the root of the
+// repository, and the namespace manifests they sit alongside. A file that can't be parsed
+// is skipped, with its diagnostics returned.
+func scanNamespaceFiles(files map[string][]byte) ([]*ModuleUsage, hcl.Diagnostics) {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	slices.Sort(filenames)
+
+	var diags hcl.Diagnostics
+	teams := make(map[string]string)
+	for _, filename := range filenames {
+		if path.Base(filename) != github.NamespaceFile {
+			continue
+		}
+		cluster, ns, _ := github.ParseNamespacePath(filename)
+		manifest, err := github.ParseNamespaceManifest(files[filename])
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid namespace manifest",
+				Detail:   err.Error(),
+				Subject:  &hcl.Range{Filename: filename},
+			})
+			continue
+		}
+		teams[cluster+"/"+ns] = manifest.Annotations[github.TeamNameAnnotation]
+	}
+
+	var usages []*ModuleUsage
+	for _, filename := range filenames {
+		if path.Ext(filename) != ".tf" {
+			continue
+		}
+		file, fileDiags := terraform.ParseModules(files[filename], filename)
+		diags = append(diags, fileDiags...)
+		if file == nil {
+			continue
+		}
+		cluster, ns, _ := github.ParseNamespacePath(filename)
+		for _, module := range file.Modules {
+			source, err := module.ParseSource()
+			if err != nil {
+				continue
+			}
+			usages = append(usages, &ModuleUsage{
+				Cluster:   cluster,
+				Namespace: ns,
+				Team:      teams[cluster+"/"+ns],
+				Filename:  filename,
+				Module:    module,
+				Source:    source,
+			})
+		}
+	}
+
+	return usages, diags
+}
@@ -0,0 +1,35 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// DownloadArchive downloads a gzipped tarball of a repository at a ref, so that a whole tree
+// can be read with one request rather than one per file. Each file is under a top-level
+// directory named after the repository and commit. The caller must close the archive.
+func DownloadArchive(ctx context.Context, client *github.Client, owner, repo, ref string) (io.ReadCloser, error) {
+	link, _, err := client.Repositories.GetArchiveLink(ctx, owner, repo, github.Tarball, &github.RepositoryContentGetOptions{Ref: ref}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching archive link for %s/%s at %s: %w", owner, repo, ref, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading archive of %s/%s at %s: %w", owner, repo, ref, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error downloading archive of %s/%s at %s: %s", owner, repo, ref, resp.Status)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,31 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDownloadArchive(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/tarball/main", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, client.BaseURL.String()+"archive/r.tar.gz", http.StatusFound)
+	})
+	mux.HandleFunc("/archive/r.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("archive"))
+	})
+
+	archive, err := DownloadArchive(context.Background(), client, "o", "r", "main")
+	if err != nil {
+		t.Fatalf("DownloadArchive() error = %v", err)
+	}
+	defer archive.Close()
+	if got, _ := io.ReadAll(archive); string(got) != "archive" {
+		t.Errorf("DownloadArchive() = %q, want %q", got, "archive")
+	}
+
+	if _, err := DownloadArchive(context.Background(), client, "o", "missing", "main"); err == nil {
+		t.Errorf("DownloadArchive() expected an error for a missing repository")
+	}
+}
@@ -7,10 +7,29 @@ import (
 	"github.com/google/go-github/v64/github"
 )
 
+// GetPullRequestBranch returns the name of the branch a pull request was opened from.
 func GetPullRequestBranch(client *github.Client, ctx context.Context, owner, repo string, prNumber int) (string, error) {
 	pull, _, err := client.PullRequests.Get(ctx, owner, repo, prNumber)
 	if err != nil {
 		return "", fmt.Errorf("error fetching pull request: %w", err)
 	}
-	return *pull.Head.Ref, nil
+	return pull.GetHead().GetRef(), nil
+}
+
+// CreateBranch creates a branch pointing at the current commit of the base branch.
+func CreateBranch(ctx context.Context, client *github.Client, owner, repo, branch, base string) (*github.Reference, error) {
+	baseRef, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+base)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching base branch %s: %w", base, err)
+	}
+
+	ref, _, err := client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.GetObject().SHA},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating branch %s: %w", branch, err)
+	}
+
+	return ref, nil
 }
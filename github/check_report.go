@@ -0,0 +1,201 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/go-github/v64/github"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// maxAnnotationsPerRequest is the most annotations GitHub accepts in a single check run request.
+const maxAnnotationsPerRequest = 50
+
+// Annotation levels accepted by GitHub.
+const (
+	AnnotationNotice  = "notice"
+	AnnotationWarning = "warning"
+	AnnotationFailure = "failure"
+)
+
+// Annotation points at one or more lines of a file in the repository,
+// for example an offending line in namespaces/live/<cluster>/<namespace>/resources/main.tf.
+type Annotation struct {
+	// Path is relative to the root of the repository.
+	Path      string
+	StartLine int
+	// EndLine defaults to StartLine when zero.
+	EndLine int
+	// Level is one of AnnotationNotice, AnnotationWarning or AnnotationFailure,
+	// and defaults to AnnotationFailure.
+	Level   string
+	Title   string
+	Message string
+}
+
+// CheckRunReport is the content of a check run created or updated by this package.
+type CheckRunReport struct {
+	// Name of the check run, e.g. "namespace-validation".
+	Name    string
+	Title   string
+	Summary string
+	// Text is optional markdown shown below the summary.
+	Text string
+	// Conclusion completes the check run when set, e.g. "success" or "failure".
+	// When empty the check run is left in progress.
+	Conclusion  string
+	Annotations []Annotation
+	// DetailsURL optionally links to the full results, such as a pipeline run.
+	DetailsURL string
+}
+
+// CreateCheckRun creates a check run on a commit with the report's summary and annotations.
+// GitHub limits a request to 50 annotations, so any more are added by follow up updates.
+func CreateCheckRun(ctx context.Context, client *github.Client, owner, repo, headSHA string, report CheckRunReport) (*github.CheckRun, error) {
+	first, rest := splitAnnotations(report.Annotations)
+
+	opts := github.CreateCheckRunOptions{
+		Name:    report.Name,
+		HeadSHA: headSHA,
+		Status:  github.String("in_progress"),
+		Output:  report.output(first),
+	}
+	if report.DetailsURL != "" {
+		opts.DetailsURL = github.String(report.DetailsURL)
+	}
+	// Only complete the check run once every annotation has been sent.
+	if report.Conclusion != "" && len(rest) == 0 {
+		opts.Status = github.String("completed")
+		opts.Conclusion = github.String(report.Conclusion)
+	}
+
+	run, _, err := client.Checks.CreateCheckRun(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error creating check run %s: %w", report.Name, err)
+	}
+
+	if len(rest) == 0 {
+		return run, nil
+	}
+
+	report.Annotations = rest
+	return UpdateCheckRun(ctx, client, owner, repo, run.GetID(), report)
+}
+
+// UpdateCheckRun updates an existing check run with the report. Annotations are added to
+// those already on the check run, in batches of 50, and the conclusion is set with the last batch.
+func UpdateCheckRun(ctx context.Context, client *github.Client, owner, repo string, checkRunID int64, report CheckRunReport) (*github.CheckRun, error) {
+	remaining := report.Annotations
+	for {
+		batch, rest := splitAnnotations(remaining)
+
+		opts := github.UpdateCheckRunOptions{
+			Name:   report.Name,
+			Output: report.output(batch),
+		}
+		if report.DetailsURL != "" {
+			opts.DetailsURL = github.String(report.DetailsURL)
+		}
+		if report.Conclusion != "" && len(rest) == 0 {
+			opts.Status = github.String("completed")
+			opts.Conclusion = github.String(report.Conclusion)
+		}
+
+		run, _, err := client.Checks.UpdateCheckRun(ctx, owner, repo, checkRunID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error updating check run %s: %w", report.Name, err)
+		}
+
+		if len(rest) == 0 {
+			return run, nil
+		}
+		remaining = rest
+	}
+}
+
+// CreatePullRequestCheckRun creates a check run on the head commit of a pull request.
+func CreatePullRequestCheckRun(ctx context.Context, client *github.Client, owner, repo string, prNumber int, report CheckRunReport) (*github.CheckRun, error) {
+	pull, _, err := client.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pull request: %w", err)
+	}
+
+	return CreateCheckRun(ctx, client, owner, repo, pull.GetHead().GetSHA(), report)
+}
+
+// DiagnosticAnnotations converts HCL diagnostics, such as those from parsing a namespace's
+// terraform, into annotations. Diagnostic filenames are expected to be relative to the
+// root of the repository; diagnostics without a source range are skipped.
+func DiagnosticAnnotations(diags hcl.Diagnostics) []Annotation {
+	var annotations []Annotation
+	for _, diag := range diags {
+		if diag.Subject == nil {
+			continue
+		}
+
+		level := AnnotationFailure
+		if diag.Severity == hcl.DiagWarning {
+			level = AnnotationWarning
+		}
+
+		annotations = append(annotations, Annotation{
+			Path:      filepath.ToSlash(diag.Subject.Filename),
+			StartLine: diag.Subject.Start.Line,
+			EndLine:   diag.Subject.End.Line,
+			Level:     level,
+			Title:     diag.Summary,
+			Message:   diag.Detail,
+		})
+	}
+	return annotations
+}
+
+// output builds the check run output for the report with the given annotations.
+func (r CheckRunReport) output(annotations []Annotation) *github.CheckRunOutput {
+	output := &github.CheckRunOutput{
+		Title:   github.String(r.Title),
+		Summary: github.String(r.Summary),
+	}
+	if r.Text != "" {
+		output.Text = github.String(r.Text)
+	}
+
+	for _, a := range annotations {
+		endLine := a.EndLine
+		if endLine == 0 {
+			endLine = a.StartLine
+		}
+		level := a.Level
+		if level == "" {
+			level = AnnotationFailure
+		}
+		// The api requires a message, so fall back to the title.
+		message := a.Message
+		if message == "" {
+			message = a.Title
+		}
+
+		annotation := &github.CheckRunAnnotation{
+			Path:            github.String(a.Path),
+			StartLine:       github.Int(a.StartLine),
+			EndLine:         github.Int(endLine),
+			AnnotationLevel: github.String(level),
+			Message:         github.String(message),
+		}
+		if a.Title != "" {
+			annotation.Title = github.String(a.Title)
+		}
+		output.Annotations = append(output.Annotations, annotation)
+	}
+
+	return output
+}
+
+// splitAnnotations returns the first batch of annotations that fits in one request, and the rest.
+func splitAnnotations(annotations []Annotation) ([]Annotation, []Annotation) {
+	if len(annotations) <= maxAnnotationsPerRequest {
+		return annotations, nil
+	}
+	return annotations[:maxAnnotationsPerRequest], annotations[maxAnnotationsPerRequest:]
+}
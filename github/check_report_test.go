@@ -0,0 +1,97 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestCreateCheckRun(t *testing.T) {
+	client, mux := setup(t)
+
+	var requests []github.UpdateCheckRunOptions
+	record := func(w http.ResponseWriter, r *http.Request) {
+		var opts github.UpdateCheckRunOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, opts)
+		fmt.Fprint(w, `{"id":7}`)
+	}
+	mux.HandleFunc("/repos/o/r/check-runs", record)
+	mux.HandleFunc("/repos/o/r/check-runs/7", record)
+
+	var annotations []Annotation
+	for i := 1; i <= 120; i++ {
+		annotations = append(annotations, Annotation{
+			Path:      "namespaces/live/cluster/ns/resources/main.tf",
+			StartLine: i,
+			Message:   "not allowed",
+		})
+	}
+
+	run, err := CreateCheckRun(context.Background(), client, "o", "r", "abc", CheckRunReport{
+		Name:        "namespace-validation",
+		Title:       "Validation failed",
+		Summary:     "120 problems",
+		Conclusion:  "failure",
+		Annotations: annotations,
+	})
+	if err != nil {
+		t.Fatalf("CreateCheckRun() error = %v", err)
+	}
+	if run.GetID() != 7 {
+		t.Errorf("CreateCheckRun() id = %v, want 7", run.GetID())
+	}
+
+	wantBatches := []int{50, 50, 20}
+	if len(requests) != len(wantBatches) {
+		t.Fatalf("CreateCheckRun() made %d requests, want %d", len(requests), len(wantBatches))
+	}
+	for i, want := range wantBatches {
+		if got := len(requests[i].Output.Annotations); got != want {
+			t.Errorf("request %d has %d annotations, want %d", i, got, want)
+		}
+		last := i == len(wantBatches)-1
+		if (requests[i].Conclusion != nil) != last {
+			t.Errorf("request %d conclusion = %v, want set only on the last request", i, requests[i].GetConclusion())
+		}
+	}
+	if got := requests[0].Output.Annotations[0]; got.GetAnnotationLevel() != AnnotationFailure || got.GetEndLine() != 1 {
+		t.Errorf("annotation defaults = %v, %v, want %v, 1", got.GetAnnotationLevel(), got.GetEndLine(), AnnotationFailure)
+	}
+}
+
+func TestDiagnosticAnnotations(t *testing.T) {
+	diags := hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported argument",
+			Detail:   "An argument named \"foo\" is not expected here.",
+			Subject: &hcl.Range{
+				Filename: "namespaces/live/cluster/ns/resources/ecr.tf",
+				Start:    hcl.Pos{Line: 3},
+				End:      hcl.Pos{Line: 4},
+			},
+		},
+		{Severity: hcl.DiagWarning, Summary: "no range"},
+	}
+
+	got := DiagnosticAnnotations(diags)
+	want := Annotation{
+		Path:      "namespaces/live/cluster/ns/resources/ecr.tf",
+		StartLine: 3,
+		EndLine:   4,
+		Level:     AnnotationFailure,
+		Title:     "Unsupported argument",
+		Message:   "An argument named \"foo\" is not expected here.",
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("DiagnosticAnnotations() = %v, want [%v]", got, want)
+	}
+}
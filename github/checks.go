@@ -0,0 +1,344 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/go-github/v64/github"
+)
+
+const (
+	// DefaultPollInterval is how often WaitForCheckRuns re-fetches check runs.
+	DefaultPollInterval = 30 * time.Second
+	// DefaultWaitTimeout is how long WaitForCheckRuns waits for check runs to complete.
+	DefaultWaitTimeout = 30 * time.Minute
+)
+
+// CheckRun is the state of a single check run on a ref. A commit status, reported by
+// integrations that predate check runs, is also a CheckRun when it is required by name, see
+// CheckPolicy.Names.
+type CheckRun struct {
+	ID         int64
+	Name       string
+	Status     string
+	Conclusion string
+	URL        string
+}
+
+// Completed reports whether the check run has finished.
+func (c CheckRun) Completed() bool {
+	return c.Status == "completed"
+}
+
+// CheckRunsResult is the aggregate state of the check runs on a ref.
+type CheckRunsResult struct {
+	// Checks are the check runs the policy applies to.
+	Checks []CheckRun
+	// Missing are the names the policy requires that have no check run or commit status yet.
+	Missing []string
+	// Completed is true when every check run has finished and none are missing.
+	Completed bool
+	// Success is true when every check run has finished with a conclusion
+	// the policy treats as passing.
+	Success bool
+
+	passing []string
+}
+
+// Failed returns the completed check runs that didn't pass.
+func (r *CheckRunsResult) Failed() []CheckRun {
+	var failed []CheckRun
+	for _, check := range r.Checks {
+		if check.Completed() && !slices.Contains(r.passing, check.Conclusion) {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// Pending returns the check runs that are queued or in progress.
+func (r *CheckRunsResult) Pending() []CheckRun {
+	var pending []CheckRun
+	for _, check := range r.Checks {
+		if !check.Completed() {
+			pending = append(pending, check)
+		}
+	}
+	return pending
+}
+
+// WaitOptions configures WaitForCheckRuns.
+type WaitOptions struct {
+	// Interval is the time between polls, defaults to DefaultPollInterval.
+	Interval time.Duration
+	// Timeout is the longest to wait for check runs to complete, defaults to DefaultWaitTimeout.
+	Timeout time.Duration
+	// Policy selects the check runs to wait for and how their conclusions are treated.
+	// When nil every check run on the ref is waited for, using DefaultPassingConclusions.
+	Policy *CheckPolicy
+}
+
+// DefaultPassingConclusions are the check run conclusions GitHub itself treats as passing.
+var DefaultPassingConclusions = []string{"success", "neutral", "skipped"}
+
+// CheckPolicy controls which check runs are considered and which conclusions pass.
+type CheckPolicy struct {
+	// Names limits the check runs considered to those with these names. A name with no check
+	// run is matched against the commit statuses on the ref instead, as branch protection
+	// can require either. A named check that hasn't been created yet is reported as missing,
+	// and keeps the result pending.
+	// When nil every check run on the ref is considered, whereas an empty non-nil slice
+	// considers none, so the result passes straight away.
+	Names []string
+	// PassingConclusions are the conclusions that count as passing, for example adding
+	// "cancelled" or "action_required", or removing "skipped". Defaults to DefaultPassingConclusions.
+	PassingConclusions []string
+}
+
+// Evaluate filters check runs by the policy and works out their aggregate state.
+func (p *CheckPolicy) Evaluate(checks []CheckRun) *CheckRunsResult {
+	passing := DefaultPassingConclusions
+	if p != nil && p.PassingConclusions != nil {
+		passing = p.PassingConclusions
+	}
+
+	result := &CheckRunsResult{passing: passing}
+	if p == nil || p.Names == nil {
+		result.Checks = checks
+	} else {
+		for _, name := range p.Names {
+			i := slices.IndexFunc(checks, func(c CheckRun) bool { return c.Name == name })
+			if i < 0 {
+				result.Missing = append(result.Missing, name)
+				continue
+			}
+			result.Checks = append(result.Checks, checks[i])
+		}
+	}
+
+	// A policy naming its checks has a known set to wait for, whereas without
+	// one a ref with no check runs at all is treated as pending, as checks are
+	// often created a short while after a push.
+	hasChecks := len(result.Checks) > 0 || (p != nil && p.Names != nil)
+	result.Completed = hasChecks && len(result.Missing) == 0
+	result.Success = result.Completed
+
+	for _, check := range result.Checks {
+		if !check.Completed() {
+			result.Completed = false
+			result.Success = false
+			continue
+		}
+		if !slices.Contains(passing, check.Conclusion) {
+			result.Success = false
+		}
+	}
+
+	return result
+}
+
+// requiredChecksNotEnabled is the message of the 404 GitHub returns for a protected branch
+// that doesn't require status checks.
+const requiredChecksNotEnabled = "Required status checks not enabled"
+
+// RequiredCheckNames returns the status checks required by a branch's protection rules.
+// An unprotected branch, or one without required status checks, returns an empty slice.
+// Any other error, including a 404 for a repository or branch that doesn't exist or a token
+// that can't read branch protection, is returned rather than treated as no required checks.
+func RequiredCheckNames(ctx context.Context, client *github.Client, owner, repo, branch string) ([]string, error) {
+	required, _, err := client.Repositories.GetRequiredStatusChecks(ctx, owner, repo, branch)
+	var errResp *github.ErrorResponse
+	if errors.Is(err, github.ErrBranchNotProtected) ||
+		errors.As(err, &errResp) && errResp.Message == requiredChecksNotEnabled {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching required status checks for %s: %w", branch, err)
+	}
+
+	names := []string{}
+	for _, check := range required.GetChecks() {
+		names = append(names, check.Context)
+	}
+	for _, name := range required.GetContexts() {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// WaitForRequiredChecks waits for the check runs and commit statuses required by the branch
+// protection of a pull request's base branch, ignoring any optional ones. The policy's Names
+// are replaced by the required checks; its PassingConclusions are kept.
+func WaitForRequiredChecks(ctx context.Context, client *github.Client, owner, repo string, prNumber int, opts *WaitOptions) (*CheckRunsResult, error) {
+	pull, _, err := client.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pull request: %w", err)
+	}
+
+	names, err := RequiredCheckNames(ctx, client, owner, repo, pull.GetBase().GetRef())
+	if err != nil {
+		return nil, err
+	}
+
+	waitOpts := WaitOptions{}
+	if opts != nil {
+		waitOpts = *opts
+	}
+	policy := CheckPolicy{}
+	if waitOpts.Policy != nil {
+		policy = *waitOpts.Policy
+	}
+	policy.Names = names
+	waitOpts.Policy = &policy
+
+	return WaitForCheckRuns(ctx, client, owner, repo, pull.GetHead().GetSHA(), &waitOpts)
+}
+
+// ListCheckRuns returns every check run on a ref, following pagination.
+func ListCheckRuns(ctx context.Context, client *github.Client, owner, repo, ref string) ([]CheckRun, error) {
+	opts := &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var checks []CheckRun
+	for {
+		result, resp, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing check runs for %s: %w", ref, err)
+		}
+
+		for _, check := range result.CheckRuns {
+			checks = append(checks, CheckRun{
+				ID:         check.GetID(),
+				Name:       check.GetName(),
+				Status:     check.GetStatus(),
+				Conclusion: check.GetConclusion(),
+				URL:        check.GetHTMLURL(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return checks, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListCommitStatuses returns the latest commit status of each context on a ref as a
+// CheckRun. A pending status is in progress, and any other is completed with its state,
+// "success", "failure" or "error", as the conclusion.
+func ListCommitStatuses(ctx context.Context, client *github.Client, owner, repo, ref string) ([]CheckRun, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	var checks []CheckRun
+	for {
+		combined, resp, err := client.Repositories.GetCombinedStatus(ctx, owner, repo, ref, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching commit statuses for %s: %w", ref, err)
+		}
+
+		for _, status := range combined.Statuses {
+			check := CheckRun{
+				ID:     status.GetID(),
+				Name:   status.GetContext(),
+				Status: "completed",
+				URL:    status.GetTargetURL(),
+			}
+			if status.GetState() == "pending" {
+				check.Status = "in_progress"
+			} else {
+				check.Conclusion = status.GetState()
+			}
+			checks = append(checks, check)
+		}
+
+		if resp.NextPage == 0 {
+			return checks, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// CheckRunsStatus fetches the check runs on a ref once and returns their aggregate state
+// under the given policy. A nil policy considers every check run. When the policy names a
+// check with no check run, the ref's commit statuses are fetched too and used in its place.
+func CheckRunsStatus(ctx context.Context, client *github.Client, owner, repo, ref string, policy *CheckPolicy) (*CheckRunsResult, error) {
+	checks, err := ListCheckRuns(ctx, client, owner, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	hasCheckRun := func(name string) bool {
+		return slices.ContainsFunc(checks, func(c CheckRun) bool { return c.Name == name })
+	}
+	if policy == nil || !slices.ContainsFunc(policy.Names, func(name string) bool { return !hasCheckRun(name) }) {
+		return policy.Evaluate(checks), nil
+	}
+
+	statuses, err := ListCommitStatuses(ctx, client, owner, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		if !hasCheckRun(status.Name) {
+			checks = append(checks, status)
+		}
+	}
+	return policy.Evaluate(checks), nil
+}
+
+// WaitForCheckRuns polls the check runs on a ref until they have all completed, the
+// timeout is reached or the context is cancelled. On timeout or cancellation the last
+// fetched result is returned along with the error, so callers can see what was pending.
+func WaitForCheckRuns(ctx context.Context, client *github.Client, owner, repo, ref string, opts *WaitOptions) (*CheckRunsResult, error) {
+	interval, timeout := DefaultPollInterval, DefaultWaitTimeout
+	var policy *CheckPolicy
+	if opts != nil {
+		policy = opts.Policy
+	}
+	if opts != nil && opts.Interval > 0 {
+		interval = opts.Interval
+	}
+	if opts != nil && opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last *CheckRunsResult
+	for {
+		result, err := CheckRunsStatus(ctx, client, owner, repo, ref, policy)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, fmt.Errorf("timed out waiting for check runs on %s: %w", ref, ctx.Err())
+			}
+			return nil, err
+		}
+		last = result
+
+		if result.Completed {
+			return result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, fmt.Errorf("timed out waiting for check runs on %s: %w", ref, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// pullRequestRef returns the ref of the head commit of a pull request.
+func pullRequestRef(prNumber int) string {
+	return fmt.Sprintf("refs/pull/%d/head", prNumber)
+}
@@ -0,0 +1,265 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListCheckRuns(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/commits/refs/pull/1/head/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"total_count":2,"check_runs":[{"id":2,"name":"plan","status":"in_progress"}]}`)
+			return
+		}
+		w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next"`)
+		fmt.Fprint(w, `{"total_count":2,"check_runs":[{"id":1,"name":"lint","status":"completed","conclusion":"success"}]}`)
+	})
+
+	got, err := ListCheckRuns(context.Background(), client, "o", "r", pullRequestRef(1))
+	if err != nil {
+		t.Fatalf("ListCheckRuns() error = %v", err)
+	}
+	want := []CheckRun{
+		{ID: 1, Name: "lint", Status: "completed", Conclusion: "success"},
+		{ID: 2, Name: "plan", Status: "in_progress"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListCheckRuns() = %v, want %v", got, want)
+	}
+}
+
+func TestWaitForCheckRuns(t *testing.T) {
+	tests := []struct {
+		name        string
+		polls       []string
+		timeout     time.Duration
+		wantSuccess bool
+		wantFailed  []string
+		wantErr     bool
+	}{
+		{
+			name: "re-polls until all checks complete",
+			polls: []string{
+				`[{"name":"lint","status":"completed","conclusion":"success"},{"name":"plan","status":"queued"}]`,
+				`[{"name":"lint","status":"completed","conclusion":"success"},{"name":"plan","status":"in_progress"}]`,
+				`[{"name":"lint","status":"completed","conclusion":"success"},{"name":"plan","status":"completed","conclusion":"success"}]`,
+			},
+			wantSuccess: true,
+		},
+		{
+			name: "a single completed check doesn't end the wait",
+			polls: []string{
+				`[{"name":"lint","status":"completed","conclusion":"failure"},{"name":"plan","status":"queued"}]`,
+				`[{"name":"lint","status":"completed","conclusion":"failure"},{"name":"plan","status":"completed","conclusion":"success"}]`,
+			},
+			wantSuccess: false,
+			wantFailed:  []string{"lint"},
+		},
+		{
+			name: "times out on checks that never complete",
+			polls: []string{
+				`[{"name":"plan","status":"queued"}]`,
+			},
+			timeout: 50 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux := setup(t)
+			calls := 0
+			mux.HandleFunc("/repos/o/r/commits/sha/check-runs", func(w http.ResponseWriter, r *http.Request) {
+				poll := tt.polls[min(calls, len(tt.polls)-1)]
+				calls++
+				fmt.Fprintf(w, `{"check_runs":%s}`, poll)
+			})
+
+			opts := &WaitOptions{Interval: time.Millisecond, Timeout: tt.timeout}
+			got, err := WaitForCheckRuns(context.Background(), client, "o", "r", "sha", opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WaitForCheckRuns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("WaitForCheckRuns() error = %v, want %v", err, context.DeadlineExceeded)
+				}
+				if got == nil || len(got.Pending()) != 1 {
+					t.Errorf("WaitForCheckRuns() should return the last result with pending checks, got %v", got)
+				}
+				return
+			}
+			if calls != len(tt.polls) {
+				t.Errorf("WaitForCheckRuns() polled %d times, want %d", calls, len(tt.polls))
+			}
+			if got.Success != tt.wantSuccess {
+				t.Errorf("WaitForCheckRuns() Success = %v, want %v", got.Success, tt.wantSuccess)
+			}
+			var failed []string
+			for _, check := range got.Failed() {
+				failed = append(failed, check.Name)
+			}
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("WaitForCheckRuns() Failed = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestCheckPolicyEvaluate(t *testing.T) {
+	checks := []CheckRun{
+		{Name: "lint", Status: "completed", Conclusion: "success"},
+		{Name: "plan", Status: "completed", Conclusion: "cancelled"},
+		{Name: "optional", Status: "in_progress"},
+	}
+	tests := []struct {
+		name          string
+		policy        *CheckPolicy
+		wantCompleted bool
+		wantSuccess   bool
+		wantMissing   []string
+	}{
+		{
+			name:          "nil policy waits on every check",
+			policy:        nil,
+			wantCompleted: false,
+		},
+		{
+			name:          "named checks ignore optional ones",
+			policy:        &CheckPolicy{Names: []string{"lint", "plan"}},
+			wantCompleted: true,
+			wantSuccess:   false,
+		},
+		{
+			name:          "cancelled can be treated as passing",
+			policy:        &CheckPolicy{Names: []string{"lint", "plan"}, PassingConclusions: []string{"success", "cancelled"}},
+			wantCompleted: true,
+			wantSuccess:   true,
+		},
+		{
+			name:          "named check without a run is missing",
+			policy:        &CheckPolicy{Names: []string{"lint", "apply"}},
+			wantCompleted: false,
+			wantMissing:   []string{"apply"},
+		},
+		{
+			name:          "no required checks passes",
+			policy:        &CheckPolicy{Names: []string{}},
+			wantCompleted: true,
+			wantSuccess:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.Evaluate(checks)
+			if got.Completed != tt.wantCompleted || got.Success != tt.wantSuccess {
+				t.Errorf("Evaluate() = completed %v success %v, want %v %v", got.Completed, got.Success, tt.wantCompleted, tt.wantSuccess)
+			}
+			if !reflect.DeepEqual(got.Missing, tt.wantMissing) {
+				t.Errorf("Evaluate() Missing = %v, want %v", got.Missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestWaitForRequiredChecks(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"base":{"ref":"main"},"head":{"sha":"abc"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/branches/main/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"strict":true,"checks":[{"context":"plan"}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits/abc/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"check_runs":[{"name":"plan","status":"completed","conclusion":"success"},{"name":"optional","status":"queued"}]}`)
+	})
+
+	got, err := WaitForRequiredChecks(context.Background(), client, "o", "r", 1, &WaitOptions{Interval: time.Millisecond, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("WaitForRequiredChecks() error = %v", err)
+	}
+	if !got.Success || len(got.Checks) != 1 || got.Checks[0].Name != "plan" {
+		t.Errorf("WaitForRequiredChecks() = %+v, want only a successful plan check", got)
+	}
+}
+
+func TestWaitForRequiredCommitStatuses(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"base":{"ref":"main"},"head":{"sha":"abc"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/branches/main/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"strict":true,"contexts":["plan","ci/concourse"],"checks":[{"context":"plan"},{"context":"ci/concourse"}]}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits/abc/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"check_runs":[{"name":"plan","status":"completed","conclusion":"success"}]}`)
+	})
+	polls := 0
+	mux.HandleFunc("/repos/o/r/commits/abc/status", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		state := "pending"
+		if polls > 1 {
+			state = "failure"
+		}
+		fmt.Fprintf(w, `{"statuses":[{"id":9,"context":"ci/concourse","state":%q,"target_url":"https://ci.example.com/9"},{"context":"plan","state":"failure"}]}`, state)
+	})
+
+	got, err := WaitForRequiredChecks(context.Background(), client, "o", "r", 1, &WaitOptions{Interval: time.Millisecond, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("WaitForRequiredChecks() error = %v", err)
+	}
+	if polls != 2 || len(got.Missing) != 0 || len(got.Checks) != 2 {
+		t.Fatalf("WaitForRequiredChecks() = %+v after %d polls, want both checks after the status completes", got, polls)
+	}
+	// The plan check run is used over a commit status with the same name.
+	failed := got.Failed()
+	if got.Success || len(failed) != 1 || failed[0].Name != "ci/concourse" || failed[0].URL != "https://ci.example.com/9" {
+		t.Errorf("WaitForRequiredChecks() Failed() = %+v, want the ci/concourse status", failed)
+	}
+}
+
+func TestRequiredCheckNames(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/branches/unprotected/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Branch not protected"}`)
+	})
+	mux.HandleFunc("/repos/o/r/branches/no-checks/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Required status checks not enabled"}`)
+	})
+	mux.HandleFunc("/repos/o/r/branches/missing/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	})
+	mux.HandleFunc("/repos/o/r/branches/forbidden/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+	})
+
+	tests := []struct {
+		branch  string
+		wantErr bool
+	}{
+		{"unprotected", false},
+		{"no-checks", false},
+		{"missing", true},
+		{"forbidden", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got, err := RequiredCheckNames(context.Background(), client, "o", "r", tt.branch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RequiredCheckNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil || len(got) != 0) {
+				t.Errorf("RequiredCheckNames() = %#v, want an empty slice", got)
+			}
+		})
+	}
+}
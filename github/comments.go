@@ -0,0 +1,163 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// Review events accepted by GitHub when submitting a pull request review.
+const (
+	ReviewComment        = "COMMENT"
+	ReviewApprove        = "APPROVE"
+	ReviewRequestChanges = "REQUEST_CHANGES"
+)
+
+// LineComment is a review comment on one or more lines of a file in a pull request.
+type LineComment struct {
+	// Path is relative to the root of the repository,
+	// e.g. namespaces/live/<cluster>/<namespace>/resources/ecr.tf.
+	Path string
+	// Line is the line of the file, in the pull request's head commit, to comment on.
+	Line int
+	// StartLine is optional and makes the comment cover StartLine to Line.
+	StartLine int
+	Body      string
+}
+
+// CreateComment posts a comment on a pull request.
+func CreateComment(ctx context.Context, client *github.Client, owner, repo string, prNumber int, body string) (*github.IssueComment, error) {
+	comment, _, err := client.Issues.CreateComment(ctx, owner, repo, prNumber, &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating pull request comment: %w", err)
+	}
+	return comment, nil
+}
+
+// FindComment returns the most recent comment on a pull request containing the marker
+// added by UpsertComment, or nil if there isn't one. Anyone can write the marker, so only
+// comments by the client's own user are matched. App installation tokens can't look up
+// their user, so for those any comment by a bot account is matched, as people can't post
+// as one.
+func FindComment(ctx context.Context, client *github.Client, owner, repo string, prNumber int, marker string) (*github.IssueComment, error) {
+	login, err := authenticatedLogin(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden := commentMarker(marker)
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var found *github.IssueComment
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing pull request comments: %w", err)
+		}
+
+		// Comments are listed oldest first, so keep the last match.
+		for _, comment := range comments {
+			if !strings.Contains(comment.GetBody(), hidden) {
+				continue
+			}
+			if author := comment.GetUser(); (login != "" && author.GetLogin() == login) || (login == "" && author.GetType() == "Bot") {
+				found = comment
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return found, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// UpsertComment posts a comment on a pull request tagged with a hidden marker, or edits the
+// client's previous comment with the same marker, found with FindComment, so repeated runs
+// don't add a new comment each time.
+func UpsertComment(ctx context.Context, client *github.Client, owner, repo string, prNumber int, marker, body string) (*github.IssueComment, error) {
+	existing, err := FindComment(ctx, client, owner, repo, prNumber, marker)
+	if err != nil {
+		return nil, err
+	}
+
+	body = body + "\n\n" + commentMarker(marker)
+	if existing == nil {
+		return CreateComment(ctx, client, owner, repo, prNumber, body)
+	}
+
+	if existing.GetBody() == body {
+		return existing, nil
+	}
+
+	comment, _, err := client.Issues.EditComment(ctx, owner, repo, existing.GetID(), &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating pull request comment: %w", err)
+	}
+	return comment, nil
+}
+
+// CreateLineComments submits a review on a pull request with a comment on each of the
+// given lines, and an optional summary as the review body.
+func CreateLineComments(ctx context.Context, client *github.Client, owner, repo string, prNumber int, summary string, comments []LineComment) (*github.PullRequestReview, error) {
+	return submitReview(ctx, client, owner, repo, prNumber, ReviewComment, summary, comments)
+}
+
+// submitReview submits a pull request review with the given event, body and line comments.
+func submitReview(ctx context.Context, client *github.Client, owner, repo string, prNumber int, event, body string, comments []LineComment) (*github.PullRequestReview, error) {
+	review := &github.PullRequestReviewRequest{
+		Event: github.String(event),
+	}
+	if body != "" {
+		review.Body = github.String(body)
+	}
+
+	for _, c := range comments {
+		draft := &github.DraftReviewComment{
+			Path: github.String(c.Path),
+			Line: github.Int(c.Line),
+			Side: github.String("RIGHT"),
+			Body: github.String(c.Body),
+		}
+		if c.StartLine > 0 && c.StartLine < c.Line {
+			draft.StartLine = github.Int(c.StartLine)
+			draft.StartSide = github.String("RIGHT")
+		}
+		review.Comments = append(review.Comments, draft)
+	}
+
+	result, _, err := client.PullRequests.CreateReview(ctx, owner, repo, prNumber, review)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pull request review: %w", err)
+	}
+	return result, nil
+}
+
+// authenticatedLogin returns the login of the client's user, or "" if the client
+// authenticates as an App installation, which can't fetch its user.
+func authenticatedLogin(ctx context.Context, client *github.Client) (string, error) {
+	user, _, err := client.Users.Get(ctx, "")
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusForbidden {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching authenticated user: %w", err)
+	}
+	return user.GetLogin(), nil
+}
+
+// commentMarker returns the hidden html comment used to find a comment again.
+func commentMarker(marker string) string {
+	return "<!-- cloud-platform-go-library: " + marker + " -->"
+}
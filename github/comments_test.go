@@ -0,0 +1,119 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+)
+
+func TestUpsertComment(t *testing.T) {
+	tests := []struct {
+		name       string
+		app        bool
+		existing   string
+		wantMethod string
+		wantPath   string
+	}{
+		{
+			name:       "creates a comment when there is no previous one",
+			existing:   `[{"id":1,"body":"unrelated","user":{"login":"ci-user"}}]`,
+			wantMethod: http.MethodPost,
+			wantPath:   "/repos/o/r/issues/1/comments",
+		},
+		{
+			name:       "edits the previous comment with the marker",
+			existing:   `[{"id":1,"body":"unrelated","user":{"login":"ci-user"}},{"id":2,"body":"old\n\n<!-- cloud-platform-go-library: validation -->","user":{"login":"ci-user"}}]`,
+			wantMethod: http.MethodPatch,
+			wantPath:   "/repos/o/r/issues/comments/2",
+		},
+		{
+			name:       "ignores the marker in someone else's comment",
+			existing:   `[{"id":2,"body":"<!-- cloud-platform-go-library: validation -->","user":{"login":"someone","type":"User"}}]`,
+			wantMethod: http.MethodPost,
+			wantPath:   "/repos/o/r/issues/1/comments",
+		},
+		{
+			name:       "edits a bot's comment as an App installation",
+			app:        true,
+			existing:   `[{"id":2,"body":"old\n\n<!-- cloud-platform-go-library: validation -->","user":{"login":"my-app[bot]","type":"Bot"}}]`,
+			wantMethod: http.MethodPatch,
+			wantPath:   "/repos/o/r/issues/comments/2",
+		},
+		{
+			name:       "ignores the marker in a person's comment as an App installation",
+			app:        true,
+			existing:   `[{"id":2,"body":"<!-- cloud-platform-go-library: validation -->","user":{"login":"someone","type":"User"}}]`,
+			wantMethod: http.MethodPost,
+			wantPath:   "/repos/o/r/issues/1/comments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux := setup(t)
+			var method, path, body string
+			write := func(w http.ResponseWriter, r *http.Request) {
+				var comment github.IssueComment
+				json.NewDecoder(r.Body).Decode(&comment)
+				method, path, body = r.Method, r.URL.Path, comment.GetBody()
+				fmt.Fprint(w, `{"id":3}`)
+			}
+			mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					fmt.Fprint(w, tt.existing)
+					return
+				}
+				write(w, r)
+			})
+			mux.HandleFunc("/repos/o/r/issues/comments/2", write)
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				if tt.app {
+					http.Error(w, `{"message":"Resource not accessible by integration"}`, http.StatusForbidden)
+					return
+				}
+				fmt.Fprint(w, `{"login":"ci-user"}`)
+			})
+
+			if _, err := UpsertComment(context.Background(), client, "o", "r", 1, "validation", "new"); err != nil {
+				t.Fatalf("UpsertComment() error = %v", err)
+			}
+			if method != tt.wantMethod || path != tt.wantPath {
+				t.Errorf("UpsertComment() sent %s %s, want %s %s", method, path, tt.wantMethod, tt.wantPath)
+			}
+			if !strings.HasPrefix(body, "new") || !strings.Contains(body, commentMarker("validation")) {
+				t.Errorf("UpsertComment() body = %q, want the new body with the marker", body)
+			}
+		})
+	}
+}
+
+func TestCreateLineComments(t *testing.T) {
+	client, mux := setup(t)
+	var review github.PullRequestReviewRequest
+	mux.HandleFunc("/repos/o/r/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&review)
+		fmt.Fprint(w, `{"id":1}`)
+	})
+
+	_, err := CreateLineComments(context.Background(), client, "o", "r", 1, "2 problems", []LineComment{
+		{Path: "namespaces/live/c/ns/resources/ecr.tf", Line: 4, Body: "use the latest version"},
+		{Path: "namespaces/live/c/ns/resources/ecr.tf", StartLine: 8, Line: 10, Body: "tags don't match"},
+	})
+	if err != nil {
+		t.Fatalf("CreateLineComments() error = %v", err)
+	}
+
+	if review.GetEvent() != ReviewComment || review.GetBody() != "2 problems" || len(review.Comments) != 2 {
+		t.Fatalf("CreateLineComments() sent %v", review)
+	}
+	if c := review.Comments[0]; c.GetLine() != 4 || c.StartLine != nil || c.GetSide() != "RIGHT" {
+		t.Errorf("single line comment = %v", c)
+	}
+	if c := review.Comments[1]; c.GetLine() != 10 || c.GetStartLine() != 8 {
+		t.Errorf("multi line comment = %v", c)
+	}
+}
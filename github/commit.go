@@ -0,0 +1,151 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// FileChange is a change to one file in a commit.
+type FileChange struct {
+	// Path is relative to the root of the repository.
+	Path    string
+	Content []byte
+	// Delete removes the file, ignoring Content.
+	Delete bool
+}
+
+// ChangeRequest describes a pull request to open with a single commit of file changes.
+type ChangeRequest struct {
+	// Base is the branch to merge into, e.g. "main".
+	Base string
+	// Branch is the new branch the commit is made on.
+	Branch        string
+	CommitMessage string
+	Changes       []FileChange
+	Title         string
+	Body          string
+	Draft         bool
+	// Labels are optionally added to the pull request once it is opened.
+	Labels []string
+}
+
+// CommitFiles commits the file changes to the tip of an existing branch using the git data
+// api, so no local clone is needed. The branch is fast-forwarded to the new commit, and
+// the request fails if the branch moved on while the commit was being made.
+func CommitFiles(ctx context.Context, client *github.Client, owner, repo, branch, message string, changes []FileChange) (*github.Commit, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no file changes to commit")
+	}
+
+	ref, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+branch)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching branch %s: %w", branch, err)
+	}
+
+	parent, _, err := client.Git.GetCommit(ctx, owner, repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching commit %s: %w", ref.GetObject().GetSHA(), err)
+	}
+
+	entries := make([]*github.TreeEntry, 0, len(changes))
+	for _, change := range changes {
+		entry, err := treeEntry(ctx, client, owner, repo, change)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	tree, _, err := client.Git.CreateTree(ctx, owner, repo, parent.GetTree().GetSHA(), entries)
+	if err != nil {
+		return nil, fmt.Errorf("error creating tree: %w", err)
+	}
+
+	commit, _, err := client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message: github.String(message),
+		Tree:    &github.Tree{SHA: tree.SHA},
+		Parents: []*github.Commit{{SHA: parent.SHA}},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating commit: %w", err)
+	}
+
+	ref.Object.SHA = commit.SHA
+	if _, _, err := client.Git.UpdateRef(ctx, owner, repo, ref, false); err != nil {
+		return nil, fmt.Errorf("error updating branch %s: %w", branch, err)
+	}
+
+	return commit, nil
+}
+
+// OpenPullRequest opens a pull request from the head branch into the base branch.
+func OpenPullRequest(ctx context.Context, client *github.Client, owner, repo, head, base, title, body string, draft bool) (*github.PullRequest, error) {
+	pull, _, err := client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(head),
+		Base:  github.String(base),
+		Body:  github.String(body),
+		Draft: github.Bool(draft),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error opening pull request: %w", err)
+	}
+	return pull, nil
+}
+
+// CreateChangePullRequest creates a branch from the base branch, commits the changes to it
+// and opens a pull request, e.g. to bump a module version across namespaces/live.
+func CreateChangePullRequest(ctx context.Context, client *github.Client, owner, repo string, change ChangeRequest) (*github.PullRequest, error) {
+	if _, err := CreateBranch(ctx, client, owner, repo, change.Branch, change.Base); err != nil {
+		return nil, err
+	}
+
+	if _, err := CommitFiles(ctx, client, owner, repo, change.Branch, change.CommitMessage, change.Changes); err != nil {
+		return nil, err
+	}
+
+	pull, err := OpenPullRequest(ctx, client, owner, repo, change.Branch, change.Base, change.Title, change.Body, change.Draft)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(change.Labels) > 0 {
+		if _, _, err := client.Issues.AddLabelsToIssue(ctx, owner, repo, pull.GetNumber(), change.Labels); err != nil {
+			return pull, fmt.Errorf("error adding labels to pull request: %w", err)
+		}
+	}
+
+	return pull, nil
+}
+
+// treeEntry converts a file change into a tree entry. Text is sent inline, whereas
+// content that isn't valid UTF-8 is uploaded as a blob first so it isn't mangled.
+func treeEntry(ctx context.Context, client *github.Client, owner, repo string, change FileChange) (*github.TreeEntry, error) {
+	entry := &github.TreeEntry{
+		Path: github.String(change.Path),
+		Mode: github.String("100644"),
+		Type: github.String("blob"),
+	}
+
+	switch {
+	case change.Delete:
+		// A tree entry without content or a sha deletes the file.
+	case utf8.Valid(change.Content):
+		entry.Content = github.String(string(change.Content))
+	default:
+		blob, _, err := client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+			Content:  github.String(base64.StdEncoding.EncodeToString(change.Content)),
+			Encoding: github.String("base64"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating blob for %s: %w", change.Path, err)
+		}
+		entry.SHA = blob.SHA
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateChangePullRequest(t *testing.T) {
+	client, mux := setup(t)
+
+	var calls []string
+	var tree struct {
+		BaseTree string `json:"base_tree"`
+		Tree     []map[string]interface{}
+	}
+	var update struct {
+		SHA   string
+		Force bool
+	}
+	mux.HandleFunc("/repos/o/r/git/ref/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "get main")
+		fmt.Fprint(w, `{"ref":"refs/heads/main","object":{"sha":"main-sha"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "create branch")
+		fmt.Fprint(w, `{"ref":"refs/heads/bump","object":{"sha":"main-sha"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/ref/heads/bump", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "get branch")
+		fmt.Fprint(w, `{"ref":"refs/heads/bump","object":{"sha":"main-sha"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/commits/main-sha", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sha":"main-sha","tree":{"sha":"main-tree"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "create tree")
+		json.NewDecoder(r.Body).Decode(&tree)
+		fmt.Fprint(w, `{"sha":"new-tree"}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "create commit")
+		fmt.Fprint(w, `{"sha":"new-commit"}`)
+	})
+	mux.HandleFunc("/repos/o/r/git/refs/heads/bump", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "update branch")
+		json.NewDecoder(r.Body).Decode(&update)
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "open pull request")
+		fmt.Fprint(w, `{"number":5}`)
+	})
+	mux.HandleFunc("/repos/o/r/issues/5/labels", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "add labels")
+		fmt.Fprint(w, `[]`)
+	})
+
+	pull, err := CreateChangePullRequest(context.Background(), client, "o", "r", ChangeRequest{
+		Base:          "main",
+		Branch:        "bump",
+		CommitMessage: "Bump ecr module",
+		Title:         "Bump ecr module",
+		Labels:        []string{"automated"},
+		Changes: []FileChange{
+			{Path: "namespaces/live/c/ns/resources/ecr.tf", Content: []byte("module \"ecr\" {}\n")},
+			{Path: "namespaces/live/c/ns/resources/old.tf", Delete: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateChangePullRequest() error = %v", err)
+	}
+	if pull.GetNumber() != 5 {
+		t.Errorf("CreateChangePullRequest() number = %v, want 5", pull.GetNumber())
+	}
+
+	want := []string{"get main", "create branch", "get branch", "create tree", "create commit", "update branch", "open pull request", "add labels"}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("CreateChangePullRequest() calls = %v, want %v", calls, want)
+	}
+	if tree.BaseTree != "main-tree" || len(tree.Tree) != 2 {
+		t.Fatalf("tree = %v, want two entries on main-tree", tree)
+	}
+	if sha, ok := tree.Tree[1]["sha"]; !ok || sha != nil {
+		t.Errorf("deleted file entry = %v, want a null sha", tree.Tree[1])
+	}
+	if update.SHA != "new-commit" || update.Force {
+		t.Errorf("branch update = %+v, want a fast-forward to new-commit", update)
+	}
+}
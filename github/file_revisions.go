@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// FileRevisions is the content of a changed file before and after a pull request.
+type FileRevisions struct {
+	Change ChangedFile
+	// Base is the content at the pull request's base commit. It is nil, and
+	// BaseExists false, when the file was added.
+	Base       []byte
+	BaseExists bool
+	// Head is the content at the pull request's head commit. It is nil, and
+	// HeadExists false, when the file was removed.
+	Head       []byte
+	HeadExists bool
+}
+
+// GetFileAtRef returns the content of a file at a ref, and false if the file doesn't
+// exist there. Files over the contents api's 1MB limit are fetched with the git blobs api.
+// The contents api also returns 404 for a missing repository or ref, so that is only taken
+// to mean the file doesn't exist once the ref is found; otherwise it is returned as an error.
+func GetFileAtRef(ctx context.Context, client *github.Client, owner, repo, filename, ref string) ([]byte, bool, error) {
+	opts := &github.RepositoryContentGetOptions{Ref: ref}
+
+	content, dir, resp, err := client.Repositories.GetContents(ctx, owner, repo, filename, opts)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		if _, _, refErr := client.Repositories.GetCommitSHA1(ctx, owner, repo, ref, ""); refErr != nil {
+			return nil, false, fmt.Errorf("error fetching %s at %s: %w", filename, ref, err)
+		}
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error fetching %s at %s: %w", filename, ref, err)
+	}
+	if content == nil {
+		return nil, false, fmt.Errorf("error fetching %s at %s: path is a directory of %d entries", filename, ref, len(dir))
+	}
+
+	// The contents api leaves the content empty, with an encoding of "none",
+	// for files between 1MB and 100MB.
+	if content.GetEncoding() == "none" {
+		blob, _, err := client.Git.GetBlobRaw(ctx, owner, repo, content.GetSHA())
+		if err != nil {
+			return nil, false, fmt.Errorf("error fetching blob for %s at %s: %w", filename, ref, err)
+		}
+		return blob, true, nil
+	}
+
+	decoded, err := content.GetContent()
+	if err != nil {
+		return nil, false, fmt.Errorf("error decoding %s at %s: %w", filename, ref, err)
+	}
+
+	return []byte(decoded), true, nil
+}
+
+// GetFileRevisions returns the content of a changed file at the base and head refs.
+// A renamed file is read from its previous name at the base ref.
+func GetFileRevisions(ctx context.Context, client *github.Client, owner, repo string, change ChangedFile, baseRef, headRef string) (*FileRevisions, error) {
+	revisions := &FileRevisions{Change: change}
+
+	if change.Change != ChangeAdded {
+		basePath := change.Filename
+		if change.PreviousFilename != "" {
+			basePath = change.PreviousFilename
+		}
+		content, exists, err := GetFileAtRef(ctx, client, owner, repo, basePath, baseRef)
+		if err != nil {
+			return nil, err
+		}
+		revisions.Base, revisions.BaseExists = content, exists
+	}
+
+	if change.Change != ChangeRemoved {
+		content, exists, err := GetFileAtRef(ctx, client, owner, repo, change.Filename, headRef)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("changed file %s not found at %s", change.Filename, headRef)
+		}
+		revisions.Head, revisions.HeadExists = content, exists
+	}
+
+	return revisions, nil
+}
+
+// PullRequestFileRevisions returns the base and head content of every file changed in a
+// pull request that the selector matches. A nil selector returns every changed file.
+// The base content is read at the merge base of the pull request, which is what its
+// changes are made against, rather than the tip of the base branch that may have moved on.
+func PullRequestFileRevisions(ctx context.Context, client *github.Client, owner, repo string, prNumber int, selector *FileSelector) ([]*FileRevisions, error) {
+	pull, _, err := client.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pull request: %w", err)
+	}
+
+	comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repo, pull.GetBase().GetSHA(), pull.GetHead().GetSHA(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error comparing pull request commits: %w", err)
+	}
+	base := comparison.GetMergeBaseCommit().GetSHA()
+
+	changes, err := PullRequestChanges(ctx, client, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	if selector != nil {
+		changes = selector.SelectChanges(changes)
+	}
+
+	revisions := make([]*FileRevisions, 0, len(changes))
+	for _, change := range changes {
+		r, err := GetFileRevisions(ctx, client, owner, repo, change, base, pull.GetHead().GetSHA())
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,123 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPullRequestFileRevisions(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"base":{"sha":"main"},"head":{"sha":"head"}}`)
+	})
+	// The base branch has moved on since the pull request branched from it at "base".
+	mux.HandleFunc("/repos/o/r/compare/main...head", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"merge_base_commit":{"sha":"base"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"filename":"namespaces/live/c/ns/resources/new.tf","status":"added"},
+			{"filename":"namespaces/live/c/ns/resources/old.tf","status":"removed"},
+			{"filename":"namespaces/live/c/ns/resources/rds.tf","previous_filename":"namespaces/live/c/ns/resources/db.tf","status":"renamed"},
+			{"filename":"namespaces/live/c/ns/resources/big.tf","status":"modified"},
+			{"filename":"README.md","status":"modified"}
+		]`)
+	})
+
+	files := map[string]string{
+		"base:namespaces/live/c/ns/resources/old.tf": "old",
+		"base:namespaces/live/c/ns/resources/db.tf":  "db",
+		"head:namespaces/live/c/ns/resources/new.tf": "new",
+		"head:namespaces/live/c/ns/resources/rds.tf": "rds",
+	}
+	mux.HandleFunc("/repos/o/r/contents/", func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path[len("/repos/o/r/contents/"):]
+		ref := r.URL.Query().Get("ref")
+		if path == "namespaces/live/c/ns/resources/big.tf" {
+			fmt.Fprintf(w, `{"type":"file","encoding":"none","content":"","sha":"%s-blob"}`, ref)
+			return
+		}
+		content, ok := files[ref+":"+path]
+		if !ok {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, base64.StdEncoding.EncodeToString([]byte(content)))
+	})
+	mux.HandleFunc("/repos/o/r/git/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "large "+r.URL.Path[len("/repos/o/r/git/blobs/"):])
+	})
+	mux.HandleFunc("/repos/o/r/commits/", func(w http.ResponseWriter, r *http.Request) {
+		ref := r.URL.Path[len("/repos/o/r/commits/"):]
+		if ref != "base" && ref != "head" {
+			http.Error(w, `{"message":"No commit found for SHA"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		fmt.Fprint(w, ref)
+	})
+
+	selector, _ := NewFileSelector(DefaultFileRules)
+	got, err := PullRequestFileRevisions(context.Background(), client, "o", "r", 1, selector)
+	if err != nil {
+		t.Fatalf("PullRequestFileRevisions() error = %v", err)
+	}
+
+	want := []struct {
+		base, head             string
+		baseExists, headExists bool
+	}{
+		{"", "new", false, true},
+		{"old", "", true, false},
+		{"db", "rds", true, true},
+		{"large base-blob", "large head-blob", true, true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PullRequestFileRevisions() returned %d files, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		r := got[i]
+		if string(r.Base) != w.base || string(r.Head) != w.head || r.BaseExists != w.baseExists || r.HeadExists != w.headExists {
+			t.Errorf("%s = base %q (%v) head %q (%v), want base %q (%v) head %q (%v)",
+				r.Change.Filename, r.Base, r.BaseExists, r.Head, r.HeadExists, w.base, w.baseExists, w.head, w.headExists)
+		}
+	}
+}
+
+func TestGetFileAtRef(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/contents/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/repos/o/r/commits/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "abc")
+	})
+	mux.HandleFunc("/repos/o/r/commits/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"No commit found for SHA"}`, http.StatusUnprocessableEntity)
+	})
+
+	tests := []struct {
+		name       string
+		repo       string
+		ref        string
+		wantExists bool
+		wantErr    bool
+	}{
+		{name: "missing file", repo: "r", ref: "main"},
+		{name: "missing ref", repo: "r", ref: "missing", wantErr: true},
+		{name: "missing repository", repo: "missing", ref: "main", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, exists, err := GetFileAtRef(context.Background(), client, "o", tt.repo, "main.tf", tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFileAtRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if exists != tt.wantExists {
+				t.Errorf("GetFileAtRef() exists = %v, want %v", exists, tt.wantExists)
+			}
+		})
+	}
+}
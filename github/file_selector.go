@@ -0,0 +1,210 @@
+package github
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// FileRules configures which files a FileSelector matches. The fields have json and yaml
+// tags so the rules can be kept in a per repository config file.
+//
+// A file is selected when it matches at least one include rule (or there are none),
+// has one of the extensions (if any are set), is in one of the clusters (if any are set),
+// and matches no exclude rule.
+type FileRules struct {
+	// Include are glob patterns, where "*" matches within a path element and
+	// "**" matches across elements, e.g. "namespaces/live/**".
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Exclude are glob patterns of files never to select.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	// IncludeRegexp and ExcludeRegexp are regular expressions matched against the whole path.
+	IncludeRegexp []string `json:"includeRegexp,omitempty" yaml:"includeRegexp,omitempty"`
+	ExcludeRegexp []string `json:"excludeRegexp,omitempty" yaml:"excludeRegexp,omitempty"`
+	// Extensions are exact file extensions including the dot, e.g. ".tf".
+	// ".tf" doesn't match ".tfvars" or ".tf.bak".
+	Extensions []string `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	// Clusters limits files to namespaces in these cluster directories of namespaces/live.
+	Clusters []string `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+}
+
+// DefaultFileRules selects terraform files in a namespace, as SelectFile always has.
+var DefaultFileRules = FileRules{
+	Include:    []string{NamespacesDir + "/*/*/**"},
+	Extensions: []string{".tf"},
+}
+
+// FileSelector matches file paths against a compiled set of FileRules.
+type FileSelector struct {
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+	extensions []string
+	clusters   []string
+}
+
+// NewFileSelector compiles the rules into a FileSelector, returning an error for any
+// invalid glob or regular expression.
+func NewFileSelector(rules FileRules) (*FileSelector, error) {
+	s := &FileSelector{
+		extensions: rules.Extensions,
+		clusters:   rules.Clusters,
+	}
+
+	for _, glob := range rules.Include {
+		re, err := compileGlob(glob)
+		if err != nil {
+			return nil, err
+		}
+		s.include = append(s.include, re)
+	}
+	for _, expr := range rules.IncludeRegexp {
+		re, err := compileRegexp(expr)
+		if err != nil {
+			return nil, err
+		}
+		s.include = append(s.include, re)
+	}
+	for _, glob := range rules.Exclude {
+		re, err := compileGlob(glob)
+		if err != nil {
+			return nil, err
+		}
+		s.exclude = append(s.exclude, re)
+	}
+	for _, expr := range rules.ExcludeRegexp {
+		re, err := compileRegexp(expr)
+		if err != nil {
+			return nil, err
+		}
+		s.exclude = append(s.exclude, re)
+	}
+
+	return s, nil
+}
+
+// Match reports whether a file path is selected by the rules.
+func (s *FileSelector) Match(filename string) bool {
+	filename = path.Clean(filename)
+
+	if len(s.extensions) > 0 && !slices.Contains(s.extensions, path.Ext(filename)) {
+		return false
+	}
+
+	if len(s.clusters) > 0 {
+		cluster, _, ok := ParseNamespacePath(filename)
+		if !ok || !slices.Contains(s.clusters, cluster) {
+			return false
+		}
+	}
+
+	if len(s.include) > 0 && !slices.ContainsFunc(s.include, matches(filename)) {
+		return false
+	}
+
+	return !slices.ContainsFunc(s.exclude, matches(filename))
+}
+
+// Select returns the commit files selected by the rules.
+func (s *FileSelector) Select(files []*github.CommitFile) []*github.CommitFile {
+	var selected []*github.CommitFile
+	for _, file := range files {
+		if s.Match(file.GetFilename()) {
+			selected = append(selected, file)
+		}
+	}
+	return selected
+}
+
+// SelectChanges returns the classified changes selected by the rules.
+func (s *FileSelector) SelectChanges(changes []ChangedFile) []ChangedFile {
+	var selected []ChangedFile
+	for _, change := range changes {
+		if s.Match(change.Filename) {
+			selected = append(selected, change)
+		}
+	}
+	return selected
+}
+
+// defaultSelector is DefaultFileRules compiled, used by SelectFile.
+var defaultSelector = mustFileSelector(DefaultFileRules)
+
+// SelectFile returns the file if it is a terraform file in a namespace, and nil otherwise.
+// Use a FileSelector to select files with other rules.
+func SelectFile(file *github.CommitFile) *github.CommitFile {
+	if defaultSelector.Match(file.GetFilename()) {
+		return file
+	}
+	return nil
+}
+
+func mustFileSelector(rules FileRules) *FileSelector {
+	s, err := NewFileSelector(rules)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func matches(filename string) func(*regexp.Regexp) bool {
+	return func(re *regexp.Regexp) bool {
+		return re.MatchString(filename)
+	}
+}
+
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file regexp %q: %w", expr, err)
+	}
+	return re, nil
+}
+
+// compileGlob converts a glob pattern to an anchored regular expression.
+// "**/" matches zero or more directories, "**" matches anything, "*" matches
+// within a path element, "?" matches one character and [...] is a character class.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid file glob %q: unclosed [", glob)
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid file glob %q: %w", glob, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+)
+
+func TestFileSelector_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    FileRules
+		filename string
+		want     bool
+	}{
+		{
+			name:     "default rules select namespace terraform",
+			rules:    DefaultFileRules,
+			filename: "namespaces/live/cluster/ns/resources/main.tf",
+			want:     true,
+		},
+		{
+			name:     "default rules ignore tfvars",
+			rules:    DefaultFileRules,
+			filename: "namespaces/live/cluster/ns/resources/main.tfvars",
+		},
+		{
+			name:     "default rules ignore backups",
+			rules:    DefaultFileRules,
+			filename: "namespaces/live/cluster/ns/resources/main.tf.bak",
+		},
+		{
+			name:     "default rules ignore paths only containing namespaces/live",
+			rules:    DefaultFileRules,
+			filename: "archive/namespaces/live/cluster/ns/main.tf",
+		},
+		{
+			name:     "exclude wins over include",
+			rules:    FileRules{Include: []string{"namespaces/live/**"}, Exclude: []string{"**/versions.tf"}},
+			filename: "namespaces/live/cluster/ns/resources/versions.tf",
+		},
+		{
+			name:     "cluster filter",
+			rules:    FileRules{Clusters: []string{"live.cloud-platform.service.justice.gov.uk"}},
+			filename: "namespaces/live/live-2.cloud-platform.service.justice.gov.uk/ns/00-namespace.yaml",
+		},
+		{
+			name:     "regexp include",
+			rules:    FileRules{IncludeRegexp: []string{`^namespaces/live/[^/]+/[^/]+/0\d-.*\.yaml$`}},
+			filename: "namespaces/live/cluster/ns/03-resourcequota.yaml",
+			want:     true,
+		},
+		{
+			name:     "single star doesn't cross directories",
+			rules:    FileRules{Include: []string{"namespaces/live/*/*.yaml"}},
+			filename: "namespaces/live/cluster/ns/00-namespace.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewFileSelector(tt.rules)
+			if err != nil {
+				t.Fatalf("NewFileSelector() error = %v", err)
+			}
+			if got := s.Match(tt.filename); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFileSelectorInvalid(t *testing.T) {
+	for _, rules := range []FileRules{
+		{Include: []string{"namespaces/[live"}},
+		{ExcludeRegexp: []string{"("}},
+	} {
+		if _, err := NewFileSelector(rules); err == nil {
+			t.Errorf("NewFileSelector(%v) expected an error", rules)
+		}
+	}
+}
+
+func TestSelectFile(t *testing.T) {
+	tf := &github.CommitFile{Filename: github.String("namespaces/live/cluster/ns/resources/ecr.tf")}
+	if got := SelectFile(tf); got != tf {
+		t.Errorf("SelectFile() = %v, want %v", got, tf)
+	}
+	tfvars := &github.CommitFile{Filename: github.String("namespaces/live/cluster/ns/resources/ecr.tfvars")}
+	if got := SelectFile(tfvars); got != nil {
+		t.Errorf("SelectFile() = %v, want nil", got)
+	}
+}
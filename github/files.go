@@ -3,38 +3,192 @@ package github
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/google/go-github/v64/github"
 )
 
-func SelectFile(file *github.CommitFile) *github.CommitFile {
-	if strings.Contains(*file.Filename, "namespaces/live") && strings.Contains(*file.Filename, ".tf") {
-		return file
-	} else {
-		return nil
+// The layout of namespaces in the cloud-platform-environments repository.
+const (
+	// NamespacesDir is the directory holding each cluster's namespaces, as
+	// NamespacesDir/<cluster>/<namespace>.
+	NamespacesDir = "namespaces/live"
+	// NamespaceFile is the namespace manifest in each namespace's directory.
+	NamespaceFile = "00-namespace.yaml"
+	// TeamNameAnnotation is the annotation on a namespace manifest naming the team that owns it.
+	TeamNameAnnotation = "cloud-platform.justice.gov.uk/team-name"
+)
+
+// FileKind is the type of content in a changed file.
+type FileKind string
+
+const (
+	// FileKindTerraform is a terraform file, usually in a namespace's resources directory.
+	FileKindTerraform FileKind = "terraform"
+	// FileKindKubernetes is a Kubernetes manifest in a namespace directory.
+	FileKindKubernetes FileKind = "kubernetes"
+	// FileKindOther is any other file.
+	FileKindOther FileKind = "other"
+)
+
+// ChangeType is how a file was changed in a pull request.
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeModified ChangeType = "modified"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeRenamed  ChangeType = "renamed"
+)
+
+// ChangedFile is a file changed in a pull request, classified by namespace, kind and change.
+type ChangedFile struct {
+	Filename string
+	// PreviousFilename is set when the file was renamed.
+	PreviousFilename string
+	// Cluster and Namespace are parsed from namespaces/live/<cluster>/<namespace>/...
+	// and are empty for files outside a namespace.
+	Cluster   string
+	Namespace string
+	Kind      FileKind
+	Change    ChangeType
+	// File is the underlying file returned by the api, including its patch.
+	File *github.CommitFile
+}
+
+// ListPullRequestFiles returns every file changed in a pull request, following pagination.
+// GitHub returns at most 3000 files for a pull request.
+func ListPullRequestFiles(ctx context.Context, client *github.Client, owner, repo string, prNumber int) ([]*github.CommitFile, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	var files []*github.CommitFile
+	for {
+		page, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing pull request files: %w", err)
+		}
+		files = append(files, page...)
+
+		if resp.NextPage == 0 {
+			return files, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// PullRequestChanges lists and classifies every file changed in a pull request.
+func PullRequestChanges(ctx context.Context, client *github.Client, owner, repo string, prNumber int) ([]ChangedFile, error) {
+	files, err := ListPullRequestFiles(ctx, client, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]ChangedFile, 0, len(files))
+	for _, file := range files {
+		changes = append(changes, ClassifyFile(file))
 	}
+	return changes, nil
 }
 
+// ClassifyFile works out the namespace, kind and change type of a changed file.
+func ClassifyFile(file *github.CommitFile) ChangedFile {
+	filename := file.GetFilename()
+	cluster, namespace, _ := ParseNamespacePath(filename)
+
+	return ChangedFile{
+		Filename:         filename,
+		PreviousFilename: file.GetPreviousFilename(),
+		Cluster:          cluster,
+		Namespace:        namespace,
+		Kind:             fileKind(filename, namespace != ""),
+		Change:           changeType(file.GetStatus()),
+		File:             file,
+	}
+}
+
+// NamespaceManifestPath returns the path of a namespace's manifest from the root of the repository.
+func NamespaceManifestPath(cluster, namespace string) string {
+	return path.Join(NamespacesDir, cluster, namespace, NamespaceFile)
+}
+
+// ParseNamespacePath returns the cluster and namespace of a path in the form
+// namespaces/live/<cluster>/<namespace>/..., and false for any other path.
+func ParseNamespacePath(filename string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(path.Clean(filename), NamespacesDir+"/")
+	if !ok {
+		return "", "", false
+	}
+
+	parts := strings.Split(rest, "/")
+	// A namespace is a directory, so there must be at least one path element below it.
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// ChangesByNamespace groups changed files by "<cluster>/<namespace>". Files outside a
+// namespace are left out.
+func ChangesByNamespace(changes []ChangedFile) map[string][]ChangedFile {
+	grouped := make(map[string][]ChangedFile)
+	for _, change := range changes {
+		if change.Namespace == "" {
+			continue
+		}
+		key := change.Cluster + "/" + change.Namespace
+		grouped[key] = append(grouped[key], change)
+	}
+	return grouped
+}
+
+// fileKind works out the kind of file from its extension and whether it is in a namespace.
+func fileKind(filename string, inNamespace bool) FileKind {
+	switch path.Ext(filename) {
+	case ".tf":
+		return FileKindTerraform
+	case ".yaml", ".yml":
+		if inNamespace {
+			return FileKindKubernetes
+		}
+	}
+	return FileKindOther
+}
+
+// changeType maps the status GitHub reports for a file to a ChangeType.
+func changeType(status string) ChangeType {
+	switch status {
+	case "added", "copied":
+		return ChangeAdded
+	case "removed":
+		return ChangeRemoved
+	case "renamed":
+		return ChangeRenamed
+	default:
+		return ChangeModified
+	}
+}
+
+// GetFileContent fetches the content of a changed file at the given ref.
 func GetFileContent(client *github.Client, ctx context.Context, file *github.CommitFile, owner, repo, ref string) (*github.RepositoryContent, error) {
 	opts := &github.RepositoryContentGetOptions{
 		Ref: ref,
 	}
 
-	content, _, _, err := client.Repositories.GetContents(ctx, owner, repo, *file.Filename, opts)
+	content, _, _, err := client.Repositories.GetContents(ctx, owner, repo, file.GetFilename(), opts)
 	if err != nil {
-		fmt.Printf("Error fetching file content: %v\n", err)
-		return nil, err
+		return nil, fmt.Errorf("error fetching file content: %w", err)
 	}
 
 	return content, nil
 }
 
+// DecodeContent returns the decoded content of a file fetched with GetFileContent.
 func DecodeContent(content *github.RepositoryContent) (string, error) {
 	decodeContent, err := content.GetContent()
 	if err != nil {
-		fmt.Printf("Error decoding file content: %v\n", err)
-		return "", err
+		return "", fmt.Errorf("error decoding file content: %w", err)
 	}
 
 	return decodeContent, nil
@@ -0,0 +1,96 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseNamespacePath(t *testing.T) {
+	tests := []struct {
+		name          string
+		filename      string
+		wantCluster   string
+		wantNamespace string
+		wantOk        bool
+	}{
+		{
+			name:          "terraform resource",
+			filename:      "namespaces/live/live.cloud-platform.service.justice.gov.uk/my-ns/resources/ecr.tf",
+			wantCluster:   "live.cloud-platform.service.justice.gov.uk",
+			wantNamespace: "my-ns",
+			wantOk:        true,
+		},
+		{
+			name:          "namespace manifest",
+			filename:      "namespaces/live/cluster/my-ns/00-namespace.yaml",
+			wantCluster:   "cluster",
+			wantNamespace: "my-ns",
+			wantOk:        true,
+		},
+		{
+			name:     "file directly in a cluster directory",
+			filename: "namespaces/live/cluster/README.md",
+		},
+		{
+			name:     "outside namespaces",
+			filename: "bin/namespaces/live/cluster/my-ns/main.tf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster, namespace, ok := ParseNamespacePath(tt.filename)
+			if cluster != tt.wantCluster || namespace != tt.wantNamespace || ok != tt.wantOk {
+				t.Errorf("ParseNamespacePath() = %v, %v, %v, want %v, %v, %v", cluster, namespace, ok, tt.wantCluster, tt.wantNamespace, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestPullRequestChanges(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"filename":".github/workflows/ci.yaml","status":"modified"}]`)
+			return
+		}
+		w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next"`)
+		fmt.Fprint(w, `[
+			{"filename":"namespaces/live/c/ns1/resources/ecr.tf","status":"added"},
+			{"filename":"namespaces/live/c/ns1/01-rbac.yaml","status":"removed"},
+			{"filename":"namespaces/live/c/ns2/resources/rds.tf","previous_filename":"namespaces/live/c/ns2/resources/db.tf","status":"renamed"}
+		]`)
+	})
+
+	changes, err := PullRequestChanges(context.Background(), client, "o", "r", 1)
+	if err != nil {
+		t.Fatalf("PullRequestChanges() error = %v", err)
+	}
+
+	type summary struct {
+		Namespace string
+		Kind      FileKind
+		Change    ChangeType
+		Previous  string
+	}
+	var got []summary
+	for _, c := range changes {
+		got = append(got, summary{c.Namespace, c.Kind, c.Change, c.PreviousFilename})
+	}
+	want := []summary{
+		{"ns1", FileKindTerraform, ChangeAdded, ""},
+		{"ns1", FileKindKubernetes, ChangeRemoved, ""},
+		{"ns2", FileKindTerraform, ChangeRenamed, "namespaces/live/c/ns2/resources/db.tf"},
+		{"", FileKindOther, ChangeModified, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PullRequestChanges() = %v, want %v", got, want)
+	}
+
+	grouped := ChangesByNamespace(changes)
+	if len(grouped) != 2 || len(grouped["c/ns1"]) != 2 || len(grouped["c/ns2"]) != 1 {
+		t.Errorf("ChangesByNamespace() = %v, want two files in c/ns1 and one in c/ns2", grouped)
+	}
+}
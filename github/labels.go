@@ -0,0 +1,220 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+
+	"github.com/google/go-github/v64/github"
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Labels applied by the default labellers.
+const (
+	LabelProductionNamespace = "production-namespace"
+	LabelNewNamespace        = "new-namespace"
+	LabelECRChange           = "ecr-change"
+	// TeamLabelPrefix is prepended to the team name of each changed namespace.
+	TeamLabelPrefix = "team:"
+)
+
+const (
+	productionLabel      = "cloud-platform.justice.gov.uk/is-production"
+	ecrCredentialsSource = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials"
+	// maxLabelLength is the longest label name GitHub allows.
+	maxLabelLength = 50
+)
+
+// LabelInput is what a Labeller decides labels from.
+type LabelInput struct {
+	// Revisions are the base and head contents of the files changed in the pull request.
+	Revisions []*FileRevisions
+	// Namespaces are the namespace manifests, at the head commit, of each namespace with
+	// a changed file, keyed by "<cluster>/<namespace>". A namespace being deleted is
+	// read from the base commit instead.
+	Namespaces map[string]*v1.Namespace
+}
+
+// Labeller returns the labels a pull request should have.
+type Labeller func(input *LabelInput) []string
+
+// DefaultLabellers are the labellers used by LabelPullRequest when none are passed.
+var DefaultLabellers = []Labeller{
+	NewNamespaceLabeller,
+	ProductionNamespaceLabeller,
+	ECRChangeLabeller,
+	TeamLabeller,
+}
+
+// NewNamespaceLabeller labels pull requests that add a namespace manifest.
+func NewNamespaceLabeller(input *LabelInput) []string {
+	for _, r := range input.Revisions {
+		if r.Change.Namespace != "" && path.Base(r.Change.Filename) == NamespaceFile && r.Change.Change == ChangeAdded {
+			return []string{LabelNewNamespace}
+		}
+	}
+	return nil
+}
+
+// ProductionNamespaceLabeller labels pull requests changing a namespace labelled as production.
+func ProductionNamespaceLabeller(input *LabelInput) []string {
+	for _, ns := range input.Namespaces {
+		if ns.Labels[productionLabel] == "true" {
+			return []string{LabelProductionNamespace}
+		}
+	}
+	return nil
+}
+
+// ECRChangeLabeller labels pull requests that add, change or remove an ECR credentials module
+// block. Other changes to a file with an ECR credentials module in it aren't labelled.
+func ECRChangeLabeller(input *LabelInput) []string {
+	for _, r := range input.Revisions {
+		if r.Change.Kind != FileKindTerraform {
+			continue
+		}
+		if !maps.Equal(ecrModules(r.Base, r.Change.Filename), ecrModules(r.Head, r.Change.Filename)) {
+			return []string{LabelECRChange}
+		}
+	}
+	return nil
+}
+
+// ecrModules returns the source text of each ECR credentials module block in a terraform
+// file, keyed by block label. A file that can't be parsed has none.
+func ecrModules(content []byte, filename string) map[string]string {
+	modules := make(map[string]string)
+	file, _ := terraform.ParseModules(content, filename)
+	if file == nil {
+		return modules
+	}
+	for _, m := range file.ModulesBySource(ecrCredentialsSource) {
+		modules[m.Name] = string(m.Range.SliceBytes(content))
+	}
+	return modules
+}
+
+// TeamLabeller labels pull requests with the team name annotation of each changed namespace.
+func TeamLabeller(input *LabelInput) []string {
+	var labels []string
+	for _, ns := range input.Namespaces {
+		if team := ns.Annotations[TeamNameAnnotation]; team != "" {
+			labels = append(labels, TeamLabelPrefix+team)
+		}
+	}
+	return labels
+}
+
+// PullRequestLabels runs each labeller and returns the sorted, de-duplicated labels.
+func PullRequestLabels(input *LabelInput, labellers ...Labeller) []string {
+	var labels []string
+	for _, labeller := range labellers {
+		labels = append(labels, labeller(input)...)
+	}
+	slices.Sort(labels)
+	return slices.Compact(labels)
+}
+
+// NewLabelInput fetches the changed files of a pull request and the manifest of each
+// namespace they belong to.
+func NewLabelInput(ctx context.Context, client *github.Client, owner, repo string, prNumber int) (*LabelInput, error) {
+	pull, _, err := client.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pull request: %w", err)
+	}
+
+	revisions, err := PullRequestFileRevisions(ctx, client, owner, repo, prNumber, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &LabelInput{
+		Revisions:  revisions,
+		Namespaces: make(map[string]*v1.Namespace),
+	}
+
+	for _, r := range revisions {
+		if r.Change.Namespace == "" {
+			continue
+		}
+		key := r.Change.Cluster + "/" + r.Change.Namespace
+		if _, ok := input.Namespaces[key]; ok {
+			continue
+		}
+
+		filename := NamespaceManifestPath(r.Change.Cluster, r.Change.Namespace)
+		content, exists, err := GetFileAtRef(ctx, client, owner, repo, filename, pull.GetHead().GetSHA())
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			content, exists, err = GetFileAtRef(ctx, client, owner, repo, filename, pull.GetBase().GetSHA())
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !exists {
+			continue
+		}
+
+		ns, err := ParseNamespaceManifest(content)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", filename, err)
+		}
+		input.Namespaces[key] = ns
+	}
+
+	return input, nil
+}
+
+// LabelPullRequest works out the labels a pull request should have from its changed content,
+// using DefaultLabellers when none are passed, and adds them to the pull request.
+// Existing labels are kept. It returns the labels that were added. A label longer than GitHub
+// allows, e.g. from a long team name, is skipped and reported in the error, and the
+// others are still added.
+func LabelPullRequest(ctx context.Context, client *github.Client, owner, repo string, prNumber int, labellers ...Labeller) ([]string, error) {
+	if len(labellers) == 0 {
+		labellers = DefaultLabellers
+	}
+
+	input, err := NewLabelInput(ctx, client, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var labels, skipped []string
+	for _, label := range PullRequestLabels(input, labellers...) {
+		if len(label) > maxLabelLength {
+			skipped = append(skipped, label)
+			continue
+		}
+		labels = append(labels, label)
+	}
+
+	if len(labels) > 0 {
+		if _, _, err := client.Issues.AddLabelsToIssue(ctx, owner, repo, prNumber, labels); err != nil {
+			return nil, fmt.Errorf("error adding labels to pull request: %w", err)
+		}
+	}
+
+	if len(skipped) > 0 {
+		return labels, fmt.Errorf("skipped labels longer than the %d characters GitHub allows: %q", maxLabelLength, skipped)
+	}
+	return labels, nil
+}
+
+// ParseNamespaceManifest decodes a namespace's 00-namespace.yaml.
+func ParseNamespaceManifest(content []byte) (*v1.Namespace, error) {
+	var ns v1.Namespace
+	if err := yaml.Unmarshal(content, &ns); err != nil {
+		return nil, err
+	}
+	if ns.Kind != "Namespace" {
+		return nil, fmt.Errorf("expected a Namespace, got %q", ns.Kind)
+	}
+	return &ns, nil
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const productionNamespace = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns
+  labels:
+    cloud-platform.justice.gov.uk/is-production: "true"
+  annotations:
+    cloud-platform.justice.gov.uk/team-name: "webops"
+`
+
+const ecrModule = `module "ecr" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"
+}
+`
+
+func TestLabelPullRequest(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"base":{"sha":"base"},"head":{"sha":"head"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/compare/base...head", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"merge_base_commit":{"sha":"base"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"filename":"namespaces/live/c/ns/00-namespace.yaml","status":"added"},
+			{"filename":"namespaces/live/c/ns/resources/ecr.tf","status":"added"}
+		]`)
+	})
+	files := map[string]string{
+		"namespaces/live/c/ns/00-namespace.yaml": productionNamespace,
+		"namespaces/live/c/ns/resources/ecr.tf":  ecrModule,
+	}
+	mux.HandleFunc("/repos/o/r/contents/", func(w http.ResponseWriter, r *http.Request) {
+		content := files[r.URL.Path[len("/repos/o/r/contents/"):]]
+		fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":"%s"}`, base64.StdEncoding.EncodeToString([]byte(content)))
+	})
+	var added []string
+	mux.HandleFunc("/repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&added)
+		fmt.Fprint(w, `[]`)
+	})
+
+	longTeam := func(*LabelInput) []string {
+		return []string{TeamLabelPrefix + strings.Repeat("a", maxLabelLength)}
+	}
+
+	tests := []struct {
+		name      string
+		labellers []Labeller
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "default labellers",
+			want: []string{LabelECRChange, LabelNewNamespace, LabelProductionNamespace, "team:webops"},
+		},
+		{
+			name:      "label too long",
+			labellers: []Labeller{ProductionNamespaceLabeller, ECRChangeLabeller, longTeam},
+			want:      []string{LabelECRChange, LabelProductionNamespace},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added = nil
+			got, err := LabelPullRequest(context.Background(), client, "o", "r", 1, tt.labellers...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LabelPullRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LabelPullRequest() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(added, tt.want) {
+				t.Errorf("LabelPullRequest() added %v, want %v", added, tt.want)
+			}
+		})
+	}
+}
+
+func TestECRChangeLabeller(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		head string
+		want []string
+	}{
+		{name: "module added", head: ecrModule, want: []string{LabelECRChange}},
+		{name: "module removed", base: ecrModule, want: []string{LabelECRChange}},
+		{name: "module changed", base: ecrModule, head: strings.Replace(ecrModule, "6.1.0", "6.2.0", 1), want: []string{LabelECRChange}},
+		{name: "similarly named module", head: `source = "github.com/org/cloud-platform-terraform-ecr-credentials-fork"`},
+		{name: "commented out module", head: "# source = \"github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0\"\n"},
+		{name: "other change in a file with a module", base: ecrModule, head: ecrModule + "\nlocals {\n  name = \"app\"\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &LabelInput{Revisions: []*FileRevisions{{
+				Change: ChangedFile{Kind: FileKindTerraform},
+				Base:   []byte(tt.base),
+				Head:   []byte(tt.head),
+			}}}
+			if got := ECRChangeLabeller(input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ECRChangeLabeller() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewValidation(t *testing.T) {
+	client, mux := setup(t)
+	var event string
+	mux.HandleFunc("/repos/o/r/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		var review struct{ Event string }
+		json.NewDecoder(r.Body).Decode(&review)
+		event = review.Event
+		fmt.Fprint(w, `{"id":1}`)
+	})
+
+	if _, err := ReviewValidation(context.Background(), client, "o", "r", 1, true, "", nil); err != nil || event != ReviewApprove {
+		t.Errorf("ReviewValidation(passed) event = %v, err = %v, want %v", event, err, ReviewApprove)
+	}
+	if _, err := ReviewValidation(context.Background(), client, "o", "r", 1, false, "tags don't match", nil); err != nil || event != ReviewRequestChanges {
+		t.Errorf("ReviewValidation(failed) event = %v, err = %v, want %v", event, err, ReviewRequestChanges)
+	}
+	if _, err := ReviewValidation(context.Background(), client, "o", "r", 1, false, "", nil); err == nil {
+		t.Errorf("ReviewValidation(failed) without a body should error")
+	}
+}
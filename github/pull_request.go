@@ -2,38 +2,121 @@ package github
 
 import (
 	"context"
-	"strconv"
+	"fmt"
+	"slices"
 
 	"github.com/google/go-github/v64/github"
 )
 
+// CheckRunCompletion waits, using the default poll interval and timeout, for every
+// check run on a pull request to complete and reports whether they all passed.
 func CheckRunCompletion(ctx context.Context, client *github.Client, owner, repo string, prNumber int) (bool, error) {
-	prNumberStr := strconv.Itoa(prNumber)
-
-	checks, _, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, "refs/pull/"+prNumberStr+"/head", nil)
+	result, err := WaitForCheckRuns(ctx, client, owner, repo, pullRequestRef(prNumber), nil)
 	if err != nil {
 		return false, err
 	}
-	count := 0
-	completed := false
-
-	for !completed {
-		for _, check := range checks.CheckRuns {
-			if check.GetStatus() == "queued" || check.GetStatus() == "in_progress" {
-				completed = false
-				count++
-			} else if check.GetStatus() == "completed" {
-				completed = true
-				if completed {
-					if check.GetConclusion() == "success" {
-						return true, nil
-					} else if check.GetConclusion() == "failure" {
-						return false, nil
-					}
-					break
-				}
+	return result.Success, nil
+}
+
+// PullRequestSummary gathers the details of a pull request needed to decide whether to merge it.
+type PullRequestSummary struct {
+	Number  int
+	Title   string
+	Author  string
+	State   string
+	Draft   bool
+	BaseRef string
+	BaseSHA string
+	HeadRef string
+	HeadSHA string
+	Labels  []string
+	// Mergeable is nil while GitHub is still working out whether the pull request can be merged.
+	Mergeable      *bool
+	MergeableState string
+	ChangedFiles   int
+	// Approvals are the users whose latest review approved the pull request.
+	Approvals []string
+	// ChangesRequested are the users whose latest review requested changes.
+	ChangesRequested []string
+}
+
+// Approved reports whether the pull request has at least one approval and no
+// outstanding requests for changes.
+func (s *PullRequestSummary) Approved() bool {
+	return len(s.Approvals) > 0 && len(s.ChangesRequested) == 0
+}
+
+// HasLabel reports whether the pull request has the label.
+func (s *PullRequestSummary) HasLabel(label string) bool {
+	return slices.Contains(s.Labels, label)
+}
+
+// GetPullRequestSummary fetches a pull request and its reviews and returns a summary of them.
+func GetPullRequestSummary(ctx context.Context, client *github.Client, owner, repo string, prNumber int) (*PullRequestSummary, error) {
+	pull, _, err := client.PullRequests.Get(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pull request: %w", err)
+	}
+
+	summary := &PullRequestSummary{
+		Number:         pull.GetNumber(),
+		Title:          pull.GetTitle(),
+		Author:         pull.GetUser().GetLogin(),
+		State:          pull.GetState(),
+		Draft:          pull.GetDraft(),
+		BaseRef:        pull.GetBase().GetRef(),
+		BaseSHA:        pull.GetBase().GetSHA(),
+		HeadRef:        pull.GetHead().GetRef(),
+		HeadSHA:        pull.GetHead().GetSHA(),
+		Mergeable:      pull.Mergeable,
+		MergeableState: pull.GetMergeableState(),
+		ChangedFiles:   pull.GetChangedFiles(),
+	}
+	for _, label := range pull.Labels {
+		summary.Labels = append(summary.Labels, label.GetName())
+	}
+
+	states, err := latestReviewStates(ctx, client, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	for user, state := range states {
+		switch state {
+		case "APPROVED":
+			summary.Approvals = append(summary.Approvals, user)
+		case "CHANGES_REQUESTED":
+			summary.ChangesRequested = append(summary.ChangesRequested, user)
+		}
+	}
+	slices.Sort(summary.Approvals)
+	slices.Sort(summary.ChangesRequested)
+
+	return summary, nil
+}
+
+// latestReviewStates returns the state of each reviewer's latest approving, change
+// requesting or dismissed review. Plain comments don't change a reviewer's state.
+func latestReviewStates(ctx context.Context, client *github.Client, owner, repo string, prNumber int) (map[string]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	states := make(map[string]string)
+
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing pull request reviews: %w", err)
+		}
+
+		// Reviews are listed oldest first.
+		for _, review := range reviews {
+			switch review.GetState() {
+			case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+				states[review.GetUser().GetLogin()] = review.GetState()
 			}
 		}
+
+		if resp.NextPage == 0 {
+			return states, nil
+		}
+		opts.Page = resp.NextPage
 	}
-	return false, nil
 }
@@ -0,0 +1,60 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetPullRequestSummary(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"number":1,"title":"Add ECR","state":"open","draft":false,
+			"user":{"login":"dev"},
+			"base":{"ref":"main","sha":"base"},"head":{"ref":"ecr","sha":"head"},
+			"labels":[{"name":"new-namespace"}],
+			"mergeable":true,"mergeable_state":"clean","changed_files":3
+		}`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"user":{"login":"a"},"state":"CHANGES_REQUESTED"},
+			{"user":{"login":"a"},"state":"APPROVED"},
+			{"user":{"login":"b"},"state":"APPROVED"},
+			{"user":{"login":"b"},"state":"COMMENTED"},
+			{"user":{"login":"c"},"state":"CHANGES_REQUESTED"},
+			{"user":{"login":"c"},"state":"DISMISSED"}
+		]`)
+	})
+
+	got, err := GetPullRequestSummary(context.Background(), client, "o", "r", 1)
+	if err != nil {
+		t.Fatalf("GetPullRequestSummary() error = %v", err)
+	}
+
+	mergeable := true
+	want := &PullRequestSummary{
+		Number:         1,
+		Title:          "Add ECR",
+		Author:         "dev",
+		State:          "open",
+		BaseRef:        "main",
+		BaseSHA:        "base",
+		HeadRef:        "ecr",
+		HeadSHA:        "head",
+		Labels:         []string{"new-namespace"},
+		Mergeable:      &mergeable,
+		MergeableState: "clean",
+		ChangedFiles:   3,
+		Approvals:      []string{"a", "b"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPullRequestSummary() = %+v, want %+v", got, want)
+	}
+	if !got.Approved() || !got.HasLabel("new-namespace") {
+		t.Errorf("GetPullRequestSummary() should be approved and labelled")
+	}
+}
@@ -0,0 +1,40 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v64/github"
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
+)
+
+// LatestVersion returns the highest version tag of a repository, and false if it has none.
+// Pre-releases and tags that aren't versions are ignored.
+func LatestVersion(ctx context.Context, client *github.Client, owner, repo string) (terraform.Version, bool, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	var latest terraform.Version
+	found := false
+	for {
+		tags, resp, err := client.Repositories.ListTags(ctx, owner, repo, opts)
+		if err != nil {
+			return terraform.Version{}, false, fmt.Errorf("error listing tags of %s/%s: %w", owner, repo, err)
+		}
+
+		for _, tag := range tags {
+			v, err := terraform.ParseVersion(tag.GetName())
+			if err != nil || v.Prerelease != "" {
+				continue
+			}
+			if !found || latest.LessThan(v) {
+				latest = v
+				found = true
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return latest, found, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
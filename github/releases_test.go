@@ -0,0 +1,29 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestLatestVersion(t *testing.T) {
+	client, mux := setup(t)
+	mux.HandleFunc("/repos/o/versioned/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"7.0.0-rc.1"},{"name":"6.10.0"},{"name":"latest"},{"name":"6.2.0"}]`)
+	})
+	mux.HandleFunc("/repos/o/unversioned/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"latest"}]`)
+	})
+
+	got, ok, err := LatestVersion(context.Background(), client, "o", "versioned")
+	if err != nil || !ok || got.String() != "6.10.0" {
+		t.Errorf("LatestVersion() = %v, %v, %v, want 6.10.0", got, ok, err)
+	}
+	if _, ok, err := LatestVersion(context.Background(), client, "o", "unversioned"); err != nil || ok {
+		t.Errorf("LatestVersion() = %v, %v, want no version", ok, err)
+	}
+	if _, _, err := LatestVersion(context.Background(), client, "o", "missing"); err == nil {
+		t.Errorf("LatestVersion() expected an error for a missing repository")
+	}
+}
@@ -0,0 +1,31 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// ApprovePullRequest submits an approving review on a pull request, with an optional body.
+func ApprovePullRequest(ctx context.Context, client *github.Client, owner, repo string, prNumber int, body string) (*github.PullRequestReview, error) {
+	return submitReview(ctx, client, owner, repo, prNumber, ReviewApprove, body, nil)
+}
+
+// RequestChanges submits a review requesting changes on a pull request. GitHub requires
+// a body explaining the changes; line comments can point at the offending lines.
+func RequestChanges(ctx context.Context, client *github.Client, owner, repo string, prNumber int, body string, comments []LineComment) (*github.PullRequestReview, error) {
+	if body == "" {
+		return nil, fmt.Errorf("a body is required to request changes")
+	}
+	return submitReview(ctx, client, owner, repo, prNumber, ReviewRequestChanges, body, comments)
+}
+
+// ReviewValidation approves a pull request when validation passed, and otherwise requests
+// changes with the body and line comments explaining what failed.
+func ReviewValidation(ctx context.Context, client *github.Client, owner, repo string, prNumber int, passed bool, body string, comments []LineComment) (*github.PullRequestReview, error) {
+	if passed {
+		return ApprovePullRequest(ctx, client, owner, repo, prNumber, body)
+	}
+	return RequestChanges(ctx, client, owner, repo, prNumber, body, comments)
+}
@@ -0,0 +1,17 @@
+package github
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+	"github.com/ministryofjustice/cloud-platform-go-library/internal/githubtest"
+)
+
+// setup returns a GitHub client pointed at a test server, and the mux used to
+// register fake api handlers on it.
+func setup(t *testing.T) (*github.Client, *http.ServeMux) {
+	t.Helper()
+	client, mux, _ := githubtest.Setup(t)
+	return client, mux
+}
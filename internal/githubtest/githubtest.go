@@ -0,0 +1,28 @@
+// Package githubtest points a GitHub client at a fake api for the tests of the packages
+// that call GitHub.
+package githubtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// Setup returns a GitHub client pointed at a test server, the mux used to register fake
+// api handlers on it, and the server's url for handlers that redirect.
+func Setup(t *testing.T) (*github.Client, *http.ServeMux, string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	u, _ := url.Parse(server.URL + "/")
+	client.BaseURL = u
+	client.UploadURL = u
+
+	return client, mux, server.URL
+}
@@ -0,0 +1,348 @@
+package namespace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Metadata describes a new Cloud Platform namespace, as asked for when creating one in the
+// cloud-platform-environments repository. It becomes the namespace's annotations and labels,
+// and the tags of its terraform.
+type Metadata struct {
+	Name    string
+	Cluster string
+	// Environment is the environment-name label, e.g. development or production.
+	Environment  string
+	IsProduction bool
+	BusinessUnit string
+	Application  string
+	// TeamName is the team-name annotation, usually the GitHub team.
+	TeamName string
+	// GitHubTeam is given admin of the namespace. It defaults to TeamName.
+	GitHubTeam string
+	// Owner is the owner annotation, e.g. "Cloud Platform: platforms@digital.justice.gov.uk".
+	Owner        string
+	SourceCode   string
+	SlackChannel string
+	// InfrastructureSupport is the email address tagged on AWS resources. It defaults to
+	// the email address in Owner.
+	InfrastructureSupport string
+}
+
+// Validate checks the metadata has what a namespace needs, that the name is a valid
+// Kubernetes namespace name and that the cluster is a DNS subdomain, so the namespace's
+// directory can't be outside the cluster's.
+func (m Metadata) Validate() error {
+	if errs := validation.IsDNS1123Label(m.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace name %q: %s", m.Name, strings.Join(errs, ", "))
+	}
+	for _, field := range []struct{ name, value string }{
+		{"cluster", m.Cluster},
+		{"environment", m.Environment},
+		{"business unit", m.BusinessUnit},
+		{"application", m.Application},
+		{"team name", m.TeamName},
+		{"owner", m.Owner},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			return fmt.Errorf("namespace %s has no %s", m.Name, field.name)
+		}
+	}
+	if errs := validation.IsDNS1123Subdomain(m.Cluster); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster name %q: %s", m.Cluster, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Dir is the namespace's directory in the cloud-platform-environments repository,
+// namespaces/live/<cluster>/<namespace>.
+func (m Metadata) Dir() string {
+	return path.Join("namespaces", "live", m.Cluster, m.Name)
+}
+
+// namespaceTemplates are the files of a new namespace, relative to its directory.
+var namespaceTemplates = []struct {
+	name string
+	text string
+}{
+	{"00-namespace.yaml", namespaceYAML},
+	{"01-rbac.yaml", rbacYAML},
+	{"02-limitrange.yaml", limitRangeYAML},
+	{"03-resourcequota.yaml", resourceQuotaYAML},
+	{"04-networkpolicy.yaml", networkPolicyYAML},
+	{"resources/main.tf", mainTF},
+	{"resources/variables.tf", variablesTF},
+	{"resources/versions.tf", versionsTF},
+}
+
+// GenerateNamespace renders the files of a new namespace: its namespace, RBAC, limit range,
+// resource quota and network policies, and the terraform in resources to add AWS resources to.
+// The files are keyed by their path in the cloud-platform-environments repository, so they
+// can be committed with github.CreateChangePullRequest.
+func GenerateNamespace(m Metadata) (map[string][]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	if m.GitHubTeam == "" {
+		m.GitHubTeam = m.TeamName
+	}
+	if m.InfrastructureSupport == "" {
+		m.InfrastructureSupport = ownerEmail(m.Owner)
+	}
+
+	funcs := template.FuncMap{"yaml": yamlString, "hcl": hclString}
+	files := make(map[string][]byte, len(namespaceTemplates))
+	for _, t := range namespaceTemplates {
+		tmpl, err := template.New(t.name).Funcs(funcs).Parse(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template %s: %w", t.name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, m); err != nil {
+			return nil, fmt.Errorf("error rendering %s: %w", t.name, err)
+		}
+		content := buf.Bytes()
+		if strings.HasSuffix(t.name, ".tf") {
+			content = hclwrite.Format(content)
+		}
+		files[path.Join(m.Dir(), t.name)] = content
+	}
+	return files, nil
+}
+
+// ownerEmail returns the email address in an owner annotation, "<team>: <email>", or the
+// annotation itself if it has no team.
+func ownerEmail(owner string) string {
+	if _, email, ok := strings.Cut(owner, ":"); ok {
+		return strings.TrimSpace(email)
+	}
+	return strings.TrimSpace(owner)
+}
+
+// yamlString writes a string as a double-quoted YAML scalar; JSON strings are valid YAML.
+func yamlString(s string) (string, error) {
+	quoted, err := json.Marshal(s)
+	return string(quoted), err
+}
+
+// hclString writes a string as an HCL string literal, escaping any template sequences.
+func hclString(s string) string {
+	return string(hclwrite.TokensForValue(cty.StringVal(s)).Bytes())
+}
+
+const namespaceYAML = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Name }}
+  labels:
+    cloud-platform.justice.gov.uk/is-production: "{{ .IsProduction }}"
+    cloud-platform.justice.gov.uk/environment-name: {{ yaml .Environment }}
+    pod-security.kubernetes.io/enforce: restricted
+  annotations:
+    cloud-platform.justice.gov.uk/business-unit: {{ yaml .BusinessUnit }}
+    cloud-platform.justice.gov.uk/application: {{ yaml .Application }}
+    cloud-platform.justice.gov.uk/owner: {{ yaml .Owner }}
+    cloud-platform.justice.gov.uk/source-code: {{ yaml .SourceCode }}
+    cloud-platform.justice.gov.uk/slack-channel: {{ yaml .SlackChannel }}
+    cloud-platform.justice.gov.uk/team-name: {{ yaml .TeamName }}
+`
+
+const rbacYAML = `kind: RoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: {{ .Name }}-admin
+  namespace: {{ .Name }}
+subjects:
+  - kind: Group
+    name: {{ yaml (printf "github:%s" .GitHubTeam) }}
+    apiGroup: rbac.authorization.k8s.io
+roleRef:
+  kind: ClusterRole
+  name: admin
+  apiGroup: rbac.authorization.k8s.io
+`
+
+const limitRangeYAML = `apiVersion: v1
+kind: LimitRange
+metadata:
+  name: limitrange
+  namespace: {{ .Name }}
+spec:
+  limits:
+    - default:
+        cpu: 1000m
+        memory: 1000Mi
+      defaultRequest:
+        cpu: 10m
+        memory: 100Mi
+      type: Container
+`
+
+const resourceQuotaYAML = `apiVersion: v1
+kind: ResourceQuota
+metadata:
+  name: namespace-quota
+  namespace: {{ .Name }}
+spec:
+  hard:
+    pods: "50"
+`
+
+const networkPolicyYAML = `kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata:
+  name: default
+  namespace: {{ .Name }}
+spec:
+  podSelector: {}
+  policyTypes:
+    - Ingress
+  ingress:
+    - from:
+        - podSelector: {}
+---
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata:
+  name: allow-ingress-controllers
+  namespace: {{ .Name }}
+spec:
+  podSelector: {}
+  policyTypes:
+    - Ingress
+  ingress:
+    - from:
+        - namespaceSelector:
+            matchLabels:
+              component: ingress-controllers
+---
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata:
+  name: allow-prometheus-scraping
+  namespace: {{ .Name }}
+spec:
+  podSelector: {}
+  policyTypes:
+    - Ingress
+  ingress:
+    - from:
+        - namespaceSelector:
+            matchLabels:
+              component: monitoring
+`
+
+const mainTF = `provider "aws" {
+  region = "eu-west-2"
+
+  default_tags {
+    tags = {
+      GithubTeam = var.team_name
+      business-unit = var.business_unit
+      application = var.application
+      is-production = var.is_production
+      owner = var.team_name
+      namespace = var.namespace
+      environment-name = var.environment
+      infrastructure-support = var.infrastructure_support
+      source-code = "github.com/ministryofjustice/cloud-platform-environments"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "london"
+  region = "eu-west-2"
+
+  default_tags {
+    tags = {
+      GithubTeam = var.team_name
+      business-unit = var.business_unit
+      application = var.application
+      is-production = var.is_production
+      owner = var.team_name
+      namespace = var.namespace
+      environment-name = var.environment
+      infrastructure-support = var.infrastructure_support
+      source-code = "github.com/ministryofjustice/cloud-platform-environments"
+    }
+  }
+}
+
+provider "kubernetes" {}
+`
+
+const variablesTF = `variable "namespace" {
+  description = "Name of the namespace these resources are part of"
+  type        = string
+  default     = {{ hcl .Name }}
+}
+
+variable "business_unit" {
+  description = "Area of the MOJ responsible for this service"
+  type        = string
+  default     = {{ hcl .BusinessUnit }}
+}
+
+variable "application" {
+  description = "Name of the application you are deploying"
+  type        = string
+  default     = {{ hcl .Application }}
+}
+
+variable "team_name" {
+  description = "The name of your development team"
+  type        = string
+  default     = {{ hcl .TeamName }}
+}
+
+variable "environment" {
+  description = "Name of the environment type for this service"
+  type        = string
+  default     = {{ hcl .Environment }}
+}
+
+variable "infrastructure_support" {
+  description = "Email address of the team responsible this service"
+  type        = string
+  default     = {{ hcl .InfrastructureSupport }}
+}
+
+variable "is_production" {
+  description = "Whether this environment type is production or not"
+  type        = string
+  default     = "{{ .IsProduction }}"
+}
+
+variable "slack_channel" {
+  description = "Slack channel name for your team, if we need to contact you about this service"
+  type        = string
+  default     = {{ hcl .SlackChannel }}
+}
+`
+
+const versionsTF = `terraform {
+  required_version = ">= 1.2.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    kubernetes = {
+      source  = "hashicorp/kubernetes"
+      version = "~> 2.0"
+    }
+  }
+
+  backend "s3" {}
+}
+`
@@ -0,0 +1,154 @@
+package namespace_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/ministryofjustice/cloud-platform-go-library/namespace"
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var generateMetadata = namespace.Metadata{
+	Name:         "my-app-dev",
+	Cluster:      "live.cloud-platform.service.justice.gov.uk",
+	Environment:  "development",
+	BusinessUnit: "HQ",
+	Application:  `My "app" ${dev}`,
+	TeamName:     "my-team",
+	Owner:        "My Team: my-team@digital.justice.gov.uk",
+	SourceCode:   "https://github.com/ministryofjustice/my-app",
+	SlackChannel: "my-team-dev",
+}
+
+func TestGenerateNamespace(t *testing.T) {
+	files, err := namespace.GenerateNamespace(generateMetadata)
+	if err != nil {
+		t.Fatalf("GenerateNamespace() error = %v", err)
+	}
+
+	dir := "namespaces/live/live.cloud-platform.service.justice.gov.uk/my-app-dev/"
+	for _, name := range []string{
+		"00-namespace.yaml", "01-rbac.yaml", "02-limitrange.yaml", "03-resourcequota.yaml",
+		"04-networkpolicy.yaml", "resources/main.tf", "resources/variables.tf", "resources/versions.tf",
+	} {
+		if _, ok := files[dir+name]; !ok {
+			t.Errorf("GenerateNamespace() has no %s", dir+name)
+		}
+	}
+
+	var ns v1.Namespace
+	if err := yaml.UnmarshalStrict(files[dir+"00-namespace.yaml"], &ns); err != nil {
+		t.Fatalf("00-namespace.yaml isn't a namespace: %v", err)
+	}
+	if ns.Name != "my-app-dev" || ns.Annotations["cloud-platform.justice.gov.uk/application"] != generateMetadata.Application ||
+		ns.Labels["cloud-platform.justice.gov.uk/is-production"] != "false" {
+		t.Errorf("00-namespace.yaml = %+v", ns.ObjectMeta)
+	}
+
+	var binding rbacv1.RoleBinding
+	if err := yaml.UnmarshalStrict(files[dir+"01-rbac.yaml"], &binding); err != nil {
+		t.Fatalf("01-rbac.yaml isn't a role binding: %v", err)
+	}
+	if len(binding.Subjects) != 1 || binding.Subjects[0].Name != "github:my-team" || binding.RoleRef.Name != "admin" {
+		t.Errorf("01-rbac.yaml = %+v", binding)
+	}
+
+	var quota v1.ResourceQuota
+	if err := yaml.UnmarshalStrict(files[dir+"03-resourcequota.yaml"], &quota); err != nil || quota.Namespace != "my-app-dev" {
+		t.Errorf("03-resourcequota.yaml isn't a resource quota for the namespace: %v", err)
+	}
+
+	for _, doc := range bytes.Split(files[dir+"04-networkpolicy.yaml"], []byte("\n---\n")) {
+		var policy networkingv1.NetworkPolicy
+		if err := yaml.UnmarshalStrict(doc, &policy); err != nil || policy.Namespace != "my-app-dev" {
+			t.Errorf("04-networkpolicy.yaml has a document that isn't a network policy for the namespace: %v", err)
+		}
+	}
+
+	// The tags in the terraform must agree with the namespace they were generated with.
+	evalCtx, diags := terraform.NewEvalContext(map[string][]byte{
+		"variables.tf": files[dir+"resources/variables.tf"],
+	})
+	if diags.HasErrors() {
+		t.Fatalf("resources/variables.tf isn't valid terraform: %v", diags)
+	}
+	vars := evalCtx.Variables["var"]
+	if got := vars.GetAttr("application").AsString(); got != generateMetadata.Application {
+		t.Errorf("var.application = %q, want %q", got, generateMetadata.Application)
+	}
+	if got := vars.GetAttr("infrastructure_support").AsString(); got != "my-team@digital.justice.gov.uk" {
+		t.Errorf("var.infrastructure_support = %q, want the owner's email", got)
+	}
+
+	main, diags := hclsyntax.ParseConfig(files[dir+"resources/main.tf"], "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("resources/main.tf isn't valid terraform: %v", diags)
+	}
+	providers := 0
+	for _, block := range main.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "provider" || block.Labels[0] != "aws" {
+			continue
+		}
+		providers++
+		for _, inner := range block.Body.Blocks {
+			if inner.Type != "default_tags" {
+				continue
+			}
+			tags, diags := inner.Body.Attributes["tags"].Expr.Value(evalCtx)
+			if diags.HasErrors() {
+				t.Fatalf("default_tags can't be evaluated: %v", diags)
+			}
+			for key, want := range map[string]string{
+				"owner":                  "my-team",
+				"GithubTeam":             "my-team",
+				"infrastructure-support": "my-team@digital.justice.gov.uk",
+				"namespace":              "my-app-dev",
+				"environment-name":       "development",
+			} {
+				if got := tags.GetAttr(key).AsString(); got != want {
+					t.Errorf("default_tags %s = %q, want %q", key, got, want)
+				}
+			}
+		}
+	}
+	if providers != 2 {
+		t.Errorf("resources/main.tf has %d aws providers, want 2", providers)
+	}
+
+	for _, name := range []string{"resources/main.tf", "resources/versions.tf"} {
+		if _, diags := terraform.ParseModules(files[dir+name], name); diags.HasErrors() {
+			t.Errorf("%s isn't valid terraform: %v", name, diags)
+		}
+	}
+}
+
+func TestGenerateNamespaceErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*namespace.Metadata)
+		want   string
+	}{
+		{"invalid name", func(m *namespace.Metadata) { m.Name = "My_App" }, "invalid namespace name"},
+		{"no cluster", func(m *namespace.Metadata) { m.Cluster = "" }, "cluster"},
+		{"cluster with a path", func(m *namespace.Metadata) { m.Cluster = "live/../../manager" }, "invalid cluster name"},
+		{"cluster with a dot segment", func(m *namespace.Metadata) { m.Cluster = "live..cluster" }, "invalid cluster name"},
+		{"no team", func(m *namespace.Metadata) { m.TeamName = " " }, "team name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := generateMetadata
+			tt.modify(&m)
+			_, err := namespace.GenerateNamespace(m)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GenerateNamespace() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
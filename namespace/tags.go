@@ -0,0 +1,102 @@
+package namespace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/ministryofjustice/cloud-platform-go-library/client"
+	"github.com/ministryofjustice/cloud-platform-go-library/structs"
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ModuleTagMismatches are the tags of a module that disagree with its namespace.
+type ModuleTagMismatches struct {
+	Filename string
+	Module   string
+	// Range is the range of the module block's header.
+	Range      hcl.Range
+	Mismatches []terraform.TagMismatch
+	// Err is set, with no mismatches, if the module couldn't be decoded to compare its tags,
+	// e.g. because a required argument is missing.
+	Err error
+}
+
+// CheckTerraformTags compares the tags of the modules in a namespace's terraform directory,
+// usually namespaces/live/<cluster>/<namespace>/resources, with the annotations and labels
+// of the namespace in the cluster. Variables are evaluated from the directory's .tf and
+// .tfvars files. Only modules with a struct registered in the terraform package that has
+// tags are compared; tags that can't be evaluated are skipped. A module that can't be
+// decoded is reported with its error and the other modules are still compared.
+func CheckTerraformTags(c *client.KubeClient, name, dir string) ([]ModuleTagMismatches, error) {
+	ns, err := Namespace(c, name)
+	if err != nil {
+		return nil, err
+	}
+
+	evalCtx, diags := terraform.LoadEvalContext(dir)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error reading terraform in %s: %w", dir, diags)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*terraform.File
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tf") {
+			continue
+		}
+		file, diags := terraform.ParseModulesFile(filepath.Join(dir, entry.Name()))
+		if file == nil {
+			return nil, fmt.Errorf("error parsing %s: %w", entry.Name(), diags)
+		}
+		files = append(files, file)
+	}
+
+	return CompareTerraformTags(ns, files, evalCtx), nil
+}
+
+// CompareTerraformTags compares the tags of the modules in parsed terraform files with a
+// namespace's annotations and labels, as CheckTerraformTags. evalCtx may be nil.
+func CompareTerraformTags(ns *v1.Namespace, files []*terraform.File, evalCtx *hcl.EvalContext) []ModuleTagMismatches {
+	var results []ModuleTagMismatches
+	for _, file := range files {
+		for _, module := range file.Modules {
+			decoded, err := terraform.DecodeModuleWithContext(module, evalCtx)
+			if err != nil {
+				// Modules without a registered struct have no known tags.
+				if errors.Is(err, terraform.ErrModuleNotFound) {
+					continue
+				}
+				results = append(results, ModuleTagMismatches{
+					Filename: file.Filename,
+					Module:   module.Name,
+					Range:    module.DefRange,
+					Err:      fmt.Errorf("error decoding module %q in %s: %w", module.Name, file.Filename, err),
+				})
+				continue
+			}
+
+			tagged, ok := decoded.Value.(structs.TaggedModule)
+			if !ok {
+				continue
+			}
+			if mismatches := terraform.CompareTags(tagged.ModuleTags(), ns); len(mismatches) > 0 {
+				results = append(results, ModuleTagMismatches{
+					Filename:   file.Filename,
+					Module:     module.Name,
+					Range:      module.DefRange,
+					Mismatches: mismatches,
+				})
+			}
+		}
+	}
+	return results
+}
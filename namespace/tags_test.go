@@ -0,0 +1,98 @@
+package namespace_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ministryofjustice/cloud-platform-go-library/client"
+	"github.com/ministryofjustice/cloud-platform-go-library/namespace"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const tagsVariables = `variable "namespace" {
+  default = "my-app-dev"
+}
+
+variable "business_unit" {
+  default = "OCTO"
+}
+`
+
+const tagsModules = `module "rds" {
+  source        = "github.com/ministryofjustice/cloud-platform-terraform-rds-instance?ref=8.0.0"
+  namespace     = var.namespace
+  business_unit = var.business_unit
+  team_name     = "my-team"
+  is_production = "true"
+  application   = var.application
+}
+
+module "sqs" {
+  source        = "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=5.0.0"
+  namespace     = var.namespace
+  business_unit = "HQ"
+}
+
+module "ecr" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"
+}
+
+module "custom" {
+  source = "github.com/example/unregistered?ref=1.0.0"
+}
+`
+
+func TestCheckTerraformTags(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"variables.tf": tagsVariables,
+		"main.tf":      tagsModules,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &client.KubeClient{
+		Clientset: fake.NewSimpleClientset(&v1.NamespaceList{Items: []v1.Namespace{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "my-app-dev",
+				Labels: map[string]string{
+					"cloud-platform.justice.gov.uk/is-production": "false",
+				},
+				Annotations: map[string]string{
+					"cloud-platform.justice.gov.uk/business-unit": "HQ",
+					"cloud-platform.justice.gov.uk/team-name":     "my-team",
+				},
+			},
+		}}}),
+	}
+
+	got, err := namespace.CheckTerraformTags(c, "my-app-dev", dir)
+	if err != nil {
+		t.Fatalf("CheckTerraformTags() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Module != "rds" || got[0].Err != nil {
+		t.Fatalf("CheckTerraformTags() = %+v, want mismatches for the rds module", got)
+	}
+	// The ecr module is missing its required repo_name, which doesn't stop the check.
+	if got[1].Module != "ecr" || got[1].Err == nil || len(got[1].Mismatches) != 0 {
+		t.Errorf("CheckTerraformTags()[1] = %+v, want a decode error for the ecr module", got[1])
+	}
+
+	tags := make(map[string]string)
+	for _, m := range got[0].Mismatches {
+		tags[m.Tag] = m.Namespace
+	}
+	want := map[string]string{"business_unit": "HQ", "is_production": "false"}
+	if len(tags) != len(want) || tags["business_unit"] != "HQ" || tags["is_production"] != "false" {
+		t.Errorf("CheckTerraformTags() mismatches = %+v, want %v", got[0].Mismatches, want)
+	}
+
+	if _, err := namespace.CheckTerraformTags(c, "missing", dir); err == nil {
+		t.Errorf("CheckTerraformTags() expected an error for a namespace not in the cluster")
+	}
+}
@@ -0,0 +1,16 @@
+package structs
+
+import "github.com/hashicorp/hcl/v2"
+
+// DynamoDB is the cloud-platform-terraform-dynamodb-cluster module.
+type DynamoDB struct {
+	ModuleBody
+	Metadata
+
+	Source           string         `hcl:"source"`
+	HashKey          string         `hcl:"hash_key,optional"`
+	RangeKey         string         `hcl:"range_key,optional"`
+	BillingMode      string         `hcl:"billing_mode,optional"`
+	Attributes       hcl.Expression `hcl:"attributes,optional"`
+	EnableEncryption *bool          `hcl:"enable_encryption,optional"`
+}
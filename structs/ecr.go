@@ -1,9 +1,50 @@
 package structs
 
+// ECR is the cloud-platform-terraform-ecr-credentials module.
 type ECR struct {
-	Source       string   `hcl:"source"`
-	Name         string   `hcl:"repo_name"`
-	OIDC         []string `hcl:"oidc_provider"`
-	Github_Repos []string `hcl:"github_repositories"`
-	Tags         Tags     `hcl:"tags"`
+	ModuleBody
+	Metadata
+
+	Source string `hcl:"source"`
+	Name   string `hcl:"repo_name"`
+	// OIDC is the oidc_provider argument of older versions of the module,
+	// which newer versions replace with oidc_providers.
+	OIDC               []string `hcl:"oidc_provider,optional"`
+	OIDCProviders      []string `hcl:"oidc_providers,optional"`
+	Github_Repos       []string `hcl:"github_repositories,optional"`
+	GithubEnvironments []string `hcl:"github_environments,optional"`
+	// Tags is the tags argument of older versions of the module, which newer
+	// versions replace with an argument for each tag. It may have any of the keys
+	// of the Tags struct, e.g. business_unit.
+	Tags map[string]string `hcl:"tags,optional"`
+}
+
+// OIDCProviderNames returns the OIDC providers from either of oidc_providers and oidc_provider.
+func (m ECR) OIDCProviderNames() []string {
+	return append(append([]string{}, m.OIDCProviders...), m.OIDC...)
+}
+
+// ModuleTags returns the metadata the module tags its resources with, from the argument
+// for each tag, overridden by the keys of the tags argument that are set.
+func (m ECR) ModuleTags() Tags {
+	tags := m.Metadata.ModuleTags()
+	for key, value := range m.Tags {
+		switch key {
+		case "business_unit":
+			tags.Business_unit = value
+		case "application":
+			tags.Application = value
+		case "is_production":
+			tags.Is_production = value
+		case "team_name":
+			tags.Team_name = value
+		case "namespace":
+			tags.Namespace = value
+		case "environment_name":
+			tags.Environment_name = value
+		case "infrastructure_support":
+			tags.Infrastructure_support = value
+		}
+	}
+	return tags
 }
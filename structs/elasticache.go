@@ -0,0 +1,16 @@
+package structs
+
+// ElastiCache is the cloud-platform-terraform-elasticache-cluster module.
+type ElastiCache struct {
+	ModuleBody
+	Metadata
+
+	Source               string `hcl:"source"`
+	VPCName              string `hcl:"vpc_name,optional"`
+	Engine               string `hcl:"engine,optional"`
+	EngineVersion        string `hcl:"engine_version,optional"`
+	NodeType             string `hcl:"node_type,optional"`
+	NumberCacheClusters  string `hcl:"number_cache_clusters,optional"`
+	ParameterGroupName   string `hcl:"parameter_group_name,optional"`
+	AuthTokenRotatedDate string `hcl:"auth_token_rotated_date,optional"`
+}
@@ -0,0 +1,12 @@
+package structs
+
+// IRSA is the cloud-platform-terraform-irsa module, which creates an IAM role for a service account.
+type IRSA struct {
+	ModuleBody
+	Metadata
+
+	Source             string            `hcl:"source"`
+	EKSClusterName     string            `hcl:"eks_cluster_name,optional"`
+	ServiceAccountName string            `hcl:"service_account_name,optional"`
+	RolePolicyARNs     map[string]string `hcl:"role_policy_arns,optional"`
+}
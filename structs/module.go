@@ -0,0 +1,10 @@
+package structs
+
+import "github.com/hashicorp/hcl/v2"
+
+// ModuleBody is embedded in every module struct. The terraform package decodes the fields
+// of embedded structs as if they were fields of the module struct.
+type ModuleBody struct {
+	// Remain holds the arguments without a field, such as providers or count.
+	Remain hcl.Body `hcl:",remain"`
+}
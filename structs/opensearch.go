@@ -0,0 +1,16 @@
+package structs
+
+import "github.com/hashicorp/hcl/v2"
+
+// OpenSearch is the cloud-platform-terraform-opensearch module.
+type OpenSearch struct {
+	ModuleBody
+	Metadata
+
+	Source         string         `hcl:"source"`
+	VPCName        string         `hcl:"vpc_name,optional"`
+	EKSClusterName string         `hcl:"eks_cluster_name,optional"`
+	EngineVersion  string         `hcl:"engine_version,optional"`
+	ClusterConfig  hcl.Expression `hcl:"cluster_config,optional"`
+	EBSOptions     hcl.Expression `hcl:"ebs_options,optional"`
+}
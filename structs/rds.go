@@ -0,0 +1,20 @@
+package structs
+
+// RDS is the cloud-platform-terraform-rds-instance module.
+type RDS struct {
+	ModuleBody
+	Metadata
+
+	Source                 string `hcl:"source"`
+	VPCName                string `hcl:"vpc_name,optional"`
+	DBEngine               string `hcl:"db_engine,optional"`
+	DBEngineVersion        string `hcl:"db_engine_version,optional"`
+	DBInstanceClass        string `hcl:"db_instance_class,optional"`
+	DBAllocatedStorage     string `hcl:"db_allocated_storage,optional"`
+	DBMaxAllocatedStorage  string `hcl:"db_max_allocated_storage,optional"`
+	DBName                 string `hcl:"db_name,optional"`
+	RDSFamily              string `hcl:"rds_family,optional"`
+	DeletionProtection     *bool  `hcl:"deletion_protection,optional"`
+	PrepareForMajorUpgrade *bool  `hcl:"prepare_for_major_upgrade,optional"`
+	EnableIRSA             *bool  `hcl:"enable_irsa,optional"`
+}
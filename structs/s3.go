@@ -0,0 +1,17 @@
+package structs
+
+import "github.com/hashicorp/hcl/v2"
+
+// S3Bucket is the cloud-platform-terraform-s3-bucket module.
+type S3Bucket struct {
+	ModuleBody
+	Metadata
+
+	Source                    string         `hcl:"source"`
+	BucketName                string         `hcl:"bucket_name,optional"`
+	ACL                       string         `hcl:"acl,optional"`
+	Versioning                *bool          `hcl:"versioning,optional"`
+	EnableAllowBlockPubAccess *bool          `hcl:"enable_allow_block_pub_access,optional"`
+	LifecycleRule             hcl.Expression `hcl:"lifecycle_rule,optional"`
+	BucketPolicy              hcl.Expression `hcl:"bucket_policy,optional"`
+}
@@ -0,0 +1,13 @@
+package structs
+
+// ServiceAccount is the cloud-platform-terraform-serviceaccount module.
+type ServiceAccount struct {
+	ModuleBody
+
+	Source             string   `hcl:"source"`
+	Namespace          string   `hcl:"namespace,optional"`
+	KubernetesCluster  string   `hcl:"kubernetes_cluster,optional"`
+	ServiceAccountName string   `hcl:"serviceaccount_name,optional"`
+	GithubRepositories []string `hcl:"github_repositories,optional"`
+	GithubEnvironments []string `hcl:"github_environments,optional"`
+}
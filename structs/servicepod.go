@@ -0,0 +1,10 @@
+package structs
+
+// ServicePod is the cloud-platform-terraform-service-pod module.
+type ServicePod struct {
+	ModuleBody
+
+	Source             string `hcl:"source"`
+	Namespace          string `hcl:"namespace,optional"`
+	ServiceAccountName string `hcl:"service_account_name,optional"`
+}
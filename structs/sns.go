@@ -0,0 +1,11 @@
+package structs
+
+// SNS is the cloud-platform-terraform-sns-topic module.
+type SNS struct {
+	ModuleBody
+	Metadata
+
+	Source           string `hcl:"source"`
+	TopicDisplayName string `hcl:"topic_display_name,optional"`
+	EncryptSNSKMS    *bool  `hcl:"encrypt_sns_kms,optional"`
+}
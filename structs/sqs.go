@@ -0,0 +1,16 @@
+package structs
+
+// SQS is the cloud-platform-terraform-sqs module.
+type SQS struct {
+	ModuleBody
+	Metadata
+
+	Source                    string `hcl:"source"`
+	SQSName                   string `hcl:"sqs_name,optional"`
+	EncryptSQSKMS             *bool  `hcl:"encrypt_sqs_kms,optional"`
+	MessageRetentionSeconds   string `hcl:"message_retention_seconds,optional"`
+	VisibilityTimeoutSeconds  string `hcl:"visibility_timeout_seconds,optional"`
+	RedrivePolicy             string `hcl:"redrive_policy,optional"`
+	FIFOQueue                 *bool  `hcl:"fifo_queue,optional"`
+	ContentBasedDeduplication *bool  `hcl:"content_based_deduplication,optional"`
+}
@@ -1,5 +1,6 @@
 package structs
 
+// Tags is the metadata Cloud Platform modules tag their resources with.
 type Tags struct {
 	Business_unit          string `hcl:"business_unit"`
 	Application            string `hcl:"application"`
@@ -9,3 +10,33 @@ type Tags struct {
 	Environment_name       string `hcl:"environment_name"`
 	Infrastructure_support string `hcl:"infrastructure_support"`
 }
+
+// Metadata is the argument for each tag of the Cloud Platform modules that tag their
+// resources with namespace metadata, and is embedded in their structs.
+type Metadata struct {
+	BusinessUnit          string `hcl:"business_unit,optional"`
+	Application           string `hcl:"application,optional"`
+	IsProduction          string `hcl:"is_production,optional"`
+	TeamName              string `hcl:"team_name,optional"`
+	Namespace             string `hcl:"namespace,optional"`
+	EnvironmentName       string `hcl:"environment_name,optional"`
+	InfrastructureSupport string `hcl:"infrastructure_support,optional"`
+}
+
+// ModuleTags returns the metadata the module tags its resources with.
+func (m Metadata) ModuleTags() Tags {
+	return Tags{
+		Business_unit:          m.BusinessUnit,
+		Application:            m.Application,
+		Is_production:          m.IsProduction,
+		Team_name:              m.TeamName,
+		Namespace:              m.Namespace,
+		Environment_name:       m.EnvironmentName,
+		Infrastructure_support: m.InfrastructureSupport,
+	}
+}
+
+// TaggedModule is implemented by module structs that tag their resources with namespace metadata.
+type TaggedModule interface {
+	ModuleTags() Tags
+}
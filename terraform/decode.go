@@ -0,0 +1,198 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	gohcl "github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// DecodedModule is a module block decoded into the struct registered for its source.
+type DecodedModule struct {
+	// Value is the struct, by value, e.g. a structs.RDS.
+	Value interface{}
+	// Unresolved are the attributes whose expressions couldn't be evaluated, mapped to
+	// their source text, e.g. "data.aws_vpc.selected.id". Their fields are left empty, except
+	// for maps written as objects, which keep the items that could be evaluated.
+	Unresolved map[string]string
+	// Remain are the attributes the struct has no field for, such as providers or count.
+	Remain map[string]*Attribute
+}
+
+// DecodeModuleWithContext decodes a parsed module block into the struct registered for its
+// source, evaluating its arguments with ctx, which is usually from LoadEvalContext.
+// Arguments that reference something ctx doesn't have are recorded in Unresolved rather
+// than failing the decode, as are calls to functions ctx doesn't have. An error is only
+// returned when the source isn't registered, a required argument is missing or a value
+// can't be converted to its field's type.
+func DecodeModuleWithContext(module *Module, ctx *hcl.EvalContext) (*DecodedModule, error) {
+	target, ok := newModuleStruct(module.Source)
+	if !ok {
+		return nil, ErrModuleNotFound
+	}
+
+	unresolved, remain, diags := decodeBody(module.Body, ctx, target)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error decoding HCL file: %w", diags)
+	}
+
+	decoded := &DecodedModule{
+		Value:      reflect.ValueOf(target).Elem().Interface(),
+		Unresolved: make(map[string]string, len(unresolved)),
+		Remain:     make(map[string]*Attribute, len(remain)),
+	}
+	for _, name := range unresolved {
+		decoded.Unresolved[name] = module.Attributes[name].Raw
+	}
+	for name := range remain {
+		decoded.Remain[name] = module.Attributes[name]
+	}
+
+	return decoded, nil
+}
+
+// decodeBody decodes the attributes of body into the struct target points to, like
+// gohcl.DecodeBody, but tolerates attributes the struct has no field for and expressions
+// that can't be evaluated. The fields of embedded structs without an hcl tag, such as
+// structs.Metadata, are decoded as if they were the struct's own. It returns the names of
+// the unresolved attributes and the attributes without a field. If the struct has a remain
+// field, it is set to the body of the attributes without a field.
+func decodeBody(body hcl.Body, ctx *hcl.EvalContext, target interface{}) ([]string, hcl.Attributes, hcl.Diagnostics) {
+	val := reflect.ValueOf(target).Elem()
+	fields, remainField := attributeFields(val.Type())
+
+	schema, _ := gohcl.ImpliedBodySchema(target)
+	schema.Attributes = schema.Attributes[:0]
+	for _, name := range sortedKeys(fields) {
+		schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: name, Required: fields[name].required})
+	}
+
+	content, remainBody, diags := body.PartialContent(schema)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	var unresolved []string
+	for name, attr := range content.Attributes {
+		field := val.FieldByIndex(fields[name].index)
+		fieldDiags := gohcl.DecodeExpression(attr.Expr, ctx, field.Addr().Interface())
+		if !fieldDiags.HasErrors() {
+			continue
+		}
+		if !needsContext(attr.Expr) {
+			diags = append(diags, fieldDiags...)
+			continue
+		}
+		if !decodeMapItems(attr.Expr, ctx, field) {
+			field.Set(reflect.Zero(field.Type()))
+		}
+		unresolved = append(unresolved, name)
+	}
+
+	if remainField != nil {
+		val.FieldByIndex(remainField).Set(reflect.ValueOf(remainBody))
+	}
+
+	// Module blocks can contain nested blocks, such as lifecycle, which JustAttributes
+	// reports as errors; only the attributes are wanted here.
+	remain, _ := remainBody.JustAttributes()
+
+	return unresolved, remain, diags
+}
+
+// decodeMapItems decodes the items of an object into a map field one at a time, leaving out
+// the items that can't be evaluated, so that e.g. a tags object with one value from an unknown
+// variable still has its other tags. It reports whether the field was set, which it is only
+// for an object written out in full and a map with string keys.
+func decodeMapItems(expr hcl.Expression, ctx *hcl.EvalContext, field reflect.Value) bool {
+	object, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok || field.Kind() != reflect.Map || field.Type().Key().Kind() != reflect.String {
+		return false
+	}
+
+	items := reflect.MakeMap(field.Type())
+	for _, item := range object.Items {
+		key := objectKey(item.KeyExpr)
+		if key == "" {
+			continue
+		}
+		value := reflect.New(field.Type().Elem())
+		if diags := gohcl.DecodeExpression(item.ValueExpr, ctx, value.Interface()); diags.HasErrors() {
+			continue
+		}
+		items.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), value.Elem())
+	}
+	field.Set(items)
+	return true
+}
+
+// structField is a struct field decoded from an attribute.
+type structField struct {
+	// index is the field's index sequence for reflect.Value.FieldByIndex.
+	index    []int
+	required bool
+}
+
+// attributeFields returns the field for each attribute named in the hcl tags of a struct
+// type and its untagged embedded structs, and the index sequence of its remain field or nil.
+// The struct's own fields take precedence over those of embedded structs.
+func attributeFields(ty reflect.Type) (map[string]structField, []int) {
+	fields := make(map[string]structField)
+	var remain []int
+	var embedded []int
+	for i := 0; i < ty.NumField(); i++ {
+		f := ty.Field(i)
+		tag := f.Tag.Get("hcl")
+		if tag == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				embedded = append(embedded, i)
+			}
+			continue
+		}
+		name, kind, _ := strings.Cut(tag, ",")
+		switch kind {
+		case "", "attr", "optional":
+			fields[name] = structField{index: []int{i}, required: kind != "optional"}
+		case "remain":
+			remain = []int{i}
+		}
+	}
+
+	for _, i := range embedded {
+		subFields, subRemain := attributeFields(ty.Field(i).Type)
+		for name, field := range subFields {
+			if _, ok := fields[name]; !ok {
+				field.index = append([]int{i}, field.index...)
+				fields[name] = field
+			}
+		}
+		if remain == nil && subRemain != nil {
+			remain = append([]int{i}, subRemain...)
+		}
+	}
+
+	return fields, remain
+}
+
+// needsContext reports whether an expression references a variable or calls a function,
+// so failing to evaluate it may be down to the EvalContext rather than the expression.
+func needsContext(expr hcl.Expression) bool {
+	if len(expr.Variables()) > 0 {
+		return true
+	}
+	syntaxExpr, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return false
+	}
+	calls := false
+	hclsyntax.VisitAll(syntaxExpr, func(node hclsyntax.Node) hcl.Diagnostics {
+		if _, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+			calls = true
+		}
+		return nil
+	})
+	return calls
+}
@@ -0,0 +1,162 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	structs "github.com/ministryofjustice/cloud-platform-go-library/structs"
+)
+
+const variablesFile = `variable "namespace" {
+  default = "my-ns"
+}
+
+variable "business_unit" {
+  default = "Platforms"
+}
+
+variable "team_name" {}
+
+locals {
+  name        = "${local.prefix}-db"
+  prefix      = var.namespace
+  environment = lower(var.environment)
+}
+`
+
+const tfvarsFile = `business_unit = "HQ"
+environment   = "DEV"
+`
+
+const autoTfvarsFile = `business_unit = "OCTO"
+`
+
+const rdsModuleFile = `module "rds" {
+  source        = "github.com/ministryofjustice/cloud-platform-terraform-rds-instance?ref=8.0.0"
+  count         = 1
+  db_name       = local.name
+  namespace     = var.namespace
+  business_unit = var.business_unit
+  team_name     = var.team_name
+  vpc_name      = data.aws_vpc.selected.id
+  application   = upper(local.environment)
+  db_engine     = "postgres"
+
+  providers = {
+    aws = aws.london
+  }
+}
+`
+
+func TestDecodeModuleWithContext(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"variables.tf":         variablesFile,
+		"rds.tf":               rdsModuleFile,
+		"terraform.tfvars":     tfvarsFile,
+		"override.auto.tfvars": autoTfvarsFile,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, diags := LoadEvalContext(dir)
+	if diags.HasErrors() {
+		t.Fatalf("LoadEvalContext() diags = %v", diags)
+	}
+
+	file, diags := ParseModulesFile(filepath.Join(dir, "rds.tf"))
+	if diags.HasErrors() {
+		t.Fatalf("ParseModulesFile() diags = %v", diags)
+	}
+
+	decoded, err := DecodeModuleWithContext(file.Module("rds"), ctx)
+	if err != nil {
+		t.Fatalf("DecodeModuleWithContext() error = %v", err)
+	}
+
+	rds := decoded.Value.(structs.RDS)
+	got := map[string]string{
+		"db_name":       rds.DBName,
+		"namespace":     rds.Namespace,
+		"business_unit": rds.BusinessUnit,
+		"application":   rds.Application,
+		"db_engine":     rds.DBEngine,
+	}
+	for name, want := range map[string]string{
+		"db_name":       "my-ns-db",
+		"namespace":     "my-ns",
+		"business_unit": "OCTO",
+		"application":   "DEV",
+		"db_engine":     "postgres",
+	} {
+		if got[name] != want {
+			t.Errorf("DecodeModuleWithContext() %s = %q, want %q", name, got[name], want)
+		}
+	}
+
+	if rds.TeamName != "" || rds.VPCName != "" {
+		t.Errorf("DecodeModuleWithContext() unresolved fields should be empty, got %q, %q", rds.TeamName, rds.VPCName)
+	}
+	if got := decoded.Unresolved["team_name"]; got != "var.team_name" {
+		t.Errorf("DecodeModuleWithContext() Unresolved[team_name] = %q", got)
+	}
+	if got := decoded.Unresolved["vpc_name"]; got != "data.aws_vpc.selected.id" {
+		t.Errorf("DecodeModuleWithContext() Unresolved[vpc_name] = %q", got)
+	}
+	if len(decoded.Unresolved) != 2 {
+		t.Errorf("DecodeModuleWithContext() Unresolved = %v, want 2 attributes", decoded.Unresolved)
+	}
+
+	for _, name := range []string{"count", "providers"} {
+		if _, ok := decoded.Remain[name]; !ok {
+			t.Errorf("DecodeModuleWithContext() Remain is missing %s", name)
+		}
+	}
+	if rds.Remain == nil {
+		t.Fatalf("DecodeModuleWithContext() Remain body not set")
+	}
+	attrs, _ := rds.Remain.JustAttributes()
+	if len(attrs) != 2 {
+		t.Errorf("RDS.Remain attributes = %d, want 2", len(attrs))
+	}
+}
+
+func TestDecodeModuleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "variables without a context",
+			content: `module "rds" {
+  source    = "github.com/ministryofjustice/cloud-platform-terraform-rds-instance?ref=8.0.0"
+  namespace = var.namespace
+  count     = 1
+}`,
+		},
+		{
+			name: "literal of the wrong type",
+			content: `module "rds" {
+  source    = "github.com/ministryofjustice/cloud-platform-terraform-rds-instance?ref=8.0.0"
+  namespace = ["a", "b"]
+}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, diags := ParseModules([]byte(tt.content), "main.tf")
+			if diags.HasErrors() {
+				t.Fatalf("ParseModules() diags = %v", diags)
+			}
+			_, err := MapTfFileToStruct(file.Modules[0].Source, file.Modules[0].Body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MapTfFileToStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+	"github.com/hashicorp/hcl/v2"
+	structs "github.com/ministryofjustice/cloud-platform-go-library/structs"
+	v1 "k8s.io/api/core/v1"
+)
+
+// DefaultGitHubOrg is the organisation an ECR module's github_repositories must be in.
+const DefaultGitHubOrg = "ministryofjustice"
+
+// DefaultEnvironmentSuffixes are the suffixes a namespace's name can have that an ECR
+// module's repo_name may leave off, e.g. repo_name "my-app" in namespace "my-app-dev".
+var DefaultEnvironmentSuffixes = []string{"-dev", "-staging", "-preprod", "-prod"}
+
+// DefaultOIDCProviders are the OIDC providers the ecr-credentials module can grant push access to.
+var DefaultOIDCProviders = []string{"github", "circleci"}
+
+// ECRValidatorOptions configure an ECRValidator.
+type ECRValidatorOptions func(*ECRValidator)
+
+// WithGitHubOrg sets the organisation github_repositories must be in.
+func WithGitHubOrg(org string) ECRValidatorOptions {
+	return func(v *ECRValidator) {
+		v.org = org
+	}
+}
+
+// WithOIDCProviders sets the OIDC providers a module may use.
+func WithOIDCProviders(providers ...string) ECRValidatorOptions {
+	return func(v *ECRValidator) {
+		v.oidcProviders = providers
+	}
+}
+
+// WithEnvironmentSuffixes sets the suffixes of a namespace's name that repo_name may leave off.
+func WithEnvironmentSuffixes(suffixes ...string) ECRValidatorOptions {
+	return func(v *ECRValidator) {
+		v.environmentSuffixes = suffixes
+	}
+}
+
+// WithRepoNamePrefixes also allows repo_name and the namespace's name to share only a hyphen
+// separated prefix, e.g. repo_name "my-app" in namespace "my-app-feature-x". This is looser
+// than the Cloud Platform rule, as a one word repo_name matches every namespace starting
+// with that word.
+func WithRepoNamePrefixes() ECRValidatorOptions {
+	return func(v *ECRValidator) {
+		v.prefixes = true
+	}
+}
+
+// WithGitHubClient checks that each of github_repositories exists, using the client.
+// Without it the repositories are only checked to be in the organisation.
+func WithGitHubClient(client *github.Client) ECRValidatorOptions {
+	return func(v *ECRValidator) {
+		v.client = client
+	}
+}
+
+// ECRValidator checks ecr-credentials module blocks against the Cloud Platform rules:
+//   - repo_name is the namespace's name, or the name without an environment suffix,
+//     e.g. repo_name "my-app" in namespace "my-app-dev".
+//   - github_repositories are in the organisation, and exist if a client is set.
+//   - oidc_providers are allowed providers.
+//   - the module's tags agree with the namespace's annotations and labels, as CompareTags.
+type ECRValidator struct {
+	org                 string
+	oidcProviders       []string
+	environmentSuffixes []string
+	prefixes            bool
+	client              *github.Client
+}
+
+// NewECRValidator returns an ECRValidator for DefaultGitHubOrg, DefaultOIDCProviders and
+// DefaultEnvironmentSuffixes.
+func NewECRValidator(opts ...ECRValidatorOptions) *ECRValidator {
+	v := &ECRValidator{
+		org:                 DefaultGitHubOrg,
+		oidcProviders:       DefaultOIDCProviders,
+		environmentSuffixes: DefaultEnvironmentSuffixes,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Validate checks an ecr-credentials module block, evaluated with evalCtx, which may be nil.
+// ns is the namespace the module is in, read from its 00-namespace.yaml or the cluster; if
+// it is nil the namespace argument of the module is used and tags aren't compared.
+// Arguments that can't be evaluated aren't checked. An error is returned if the module
+// isn't an ecr-credentials module, can't be decoded or GitHub can't be reached.
+func (v *ECRValidator) Validate(ctx context.Context, module *Module, ns *v1.Namespace, evalCtx *hcl.EvalContext) (Violations, error) {
+	decoded, err := DecodeModuleWithContext(module, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	ecr, ok := decoded.Value.(structs.ECR)
+	if !ok {
+		return nil, fmt.Errorf("module %q is not an ecr-credentials module", module.Name)
+	}
+
+	var violations Violations
+	violation := func(attribute, format string, args ...interface{}) {
+		violations = append(violations, Violation{
+			Module: module.Name,
+			Source: module.Source,
+			Reason: fmt.Sprintf(format, args...),
+			Range:  attributeRange(module, attribute),
+		})
+	}
+
+	tags := ecr.ModuleTags()
+	namespace := tags.Namespace
+	if ns != nil {
+		namespace = ns.Name
+	}
+	if ecr.Name != "" && namespace != "" && !v.repoNameMatches(ecr.Name, namespace) {
+		violation("repo_name", "repo_name %q does not match namespace %q", ecr.Name, namespace)
+	}
+
+	for _, repository := range ecr.Github_Repos {
+		name := repository
+		if org, repo, ok := strings.Cut(repository, "/"); ok {
+			if !strings.EqualFold(org, v.org) {
+				violation("github_repositories", "github repository %q is not in the %s organisation", repository, v.org)
+				continue
+			}
+			name = repo
+		}
+		if v.client == nil {
+			continue
+		}
+		exists, err := v.repositoryExists(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			violation("github_repositories", "github repository %s/%s does not exist", v.org, name)
+		}
+	}
+
+	for _, provider := range ecr.OIDCProviderNames() {
+		if !slices.Contains(v.oidcProviders, provider) {
+			violation("oidc_providers", "oidc provider %q is not one of %s", provider, strings.Join(v.oidcProviders, ", "))
+		}
+	}
+
+	if ns != nil {
+		for _, m := range CompareTags(tags, ns) {
+			violation(m.Tag, "%s", m.Error())
+		}
+	}
+
+	return violations, nil
+}
+
+// repositoryExists reports whether a repository exists in the validator's organisation.
+func (v *ECRValidator) repositoryExists(ctx context.Context, name string) (bool, error) {
+	_, resp, err := v.client.Repositories.Get(ctx, v.org, name)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error fetching repository %s/%s: %w", v.org, name, err)
+	}
+	return true, nil
+}
+
+// repoNameMatches reports whether an ECR repo_name matches a namespace's name.
+func (v *ECRValidator) repoNameMatches(name, namespace string) bool {
+	if name == namespace {
+		return true
+	}
+	for _, suffix := range v.environmentSuffixes {
+		if base, ok := strings.CutSuffix(namespace, suffix); ok && name == base {
+			return true
+		}
+	}
+	return v.prefixes && sharesPrefix(name, namespace)
+}
+
+// sharesPrefix reports whether a and b are equal or one is a hyphen separated prefix of the other.
+func sharesPrefix(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"-") || strings.HasPrefix(b, a+"-")
+}
+
+// attributeRange returns the range of a module's attribute, of its tags argument for a
+// tag set there, or of the block header if it has neither.
+func attributeRange(module *Module, name string) hcl.Range {
+	if attr, ok := module.Attributes[name]; ok {
+		return attr.Range
+	}
+	if name == "oidc_providers" {
+		if attr, ok := module.Attributes["oidc_provider"]; ok {
+			return attr.Range
+		}
+	}
+	if attr, ok := module.Attributes["tags"]; ok {
+		return attr.Range
+	}
+	return module.DefRange
+}
@@ -0,0 +1,190 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func ecrNamespace() *v1.Namespace {
+	return &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-app-dev",
+			Labels: map[string]string{
+				"cloud-platform.justice.gov.uk/is-production":    "false",
+				"cloud-platform.justice.gov.uk/environment-name": "development",
+			},
+			Annotations: map[string]string{
+				"cloud-platform.justice.gov.uk/business-unit": "HQ",
+				"cloud-platform.justice.gov.uk/application":   "My App",
+				"cloud-platform.justice.gov.uk/team-name":     "my-team",
+				"cloud-platform.justice.gov.uk/owner":         "My Team: my-team@digital.justice.gov.uk",
+			},
+		},
+	}
+}
+
+func TestECRValidatorValidate(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc("/repos/ministryofjustice/my-app", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"my-app"}`)
+	})
+	mux.HandleFunc("/repos/ministryofjustice/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	tests := []struct {
+		name        string
+		module      string
+		wantReasons []string
+	}{
+		{
+			name: "valid",
+			module: `module "ecr" {
+  source                 = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"
+  repo_name              = "my-app"
+  oidc_providers         = ["github"]
+  github_repositories    = ["my-app"]
+  business_unit          = "HQ"
+  application            = "My App"
+  is_production          = "false"
+  team_name              = "my-team"
+  namespace              = var.namespace
+  environment_name       = "development"
+  infrastructure_support = "my-team@digital.justice.gov.uk"
+}`,
+		},
+		{
+			name: "older module version with a tags object",
+			module: `module "ecr" {
+  source              = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=4.0.0"
+  repo_name           = "my-app-dev"
+  oidc_provider       = ["circleci"]
+  github_repositories = ["ministryofjustice/my-app"]
+  tags = {
+    business_unit          = "HQ"
+    application            = "My App"
+    is_production          = "false"
+    team_name              = "my-team"
+    namespace              = "my-app-dev"
+    environment_name       = "development"
+    infrastructure_support = "my-team@digital.justice.gov.uk"
+  }
+}`,
+		},
+		{
+			name: "older module version with a partial tags object",
+			module: `module "ecr" {
+  source    = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=4.0.0"
+  repo_name = "my-app-dev"
+  tags = {
+    business_unit = "OCTO"
+    team_name     = "my-team"
+  }
+}`,
+			wantReasons: []string{
+				`business_unit is "OCTO" but the namespace annotation cloud-platform.justice.gov.uk/business-unit is "HQ"`,
+			},
+		},
+		{
+			name: "tags object with a value that can't be evaluated",
+			module: `module "ecr" {
+  source    = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=4.0.0"
+  repo_name = "my-app-dev"
+  tags = {
+    namespace     = var.namespace
+    is_production = "true"
+  }
+}`,
+			wantReasons: []string{
+				`is_production is "true" but the namespace label cloud-platform.justice.gov.uk/is-production is "false"`,
+			},
+		},
+		{
+			name: "every rule broken",
+			module: `module "ecr" {
+  source              = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"
+  repo_name           = "other-app"
+  oidc_providers      = ["github", "gitlab"]
+  github_repositories = ["missing", "evil/my-app"]
+  business_unit       = "OCTO"
+  is_production       = "true"
+}`,
+			wantReasons: []string{
+				`repo_name "other-app" does not match namespace "my-app-dev"`,
+				"github repository ministryofjustice/missing does not exist",
+				`github repository "evil/my-app" is not in the ministryofjustice organisation`,
+				`oidc provider "gitlab" is not one of github, circleci`,
+				`business_unit is "OCTO" but the namespace annotation cloud-platform.justice.gov.uk/business-unit is "HQ"`,
+				`is_production is "true" but the namespace label cloud-platform.justice.gov.uk/is-production is "false"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, diags := ParseModules([]byte(tt.module), "ecr.tf")
+			if diags.HasErrors() {
+				t.Fatalf("ParseModules() diags = %v", diags)
+			}
+
+			violations, err := NewECRValidator(WithGitHubClient(client)).Validate(context.Background(), file.Modules[0], ecrNamespace(), nil)
+			if err != nil {
+				t.Fatalf("ECRValidator.Validate() error = %v", err)
+			}
+
+			var reasons []string
+			for _, v := range violations {
+				reasons = append(reasons, v.Reason)
+			}
+			if strings.Join(reasons, "\n") != strings.Join(tt.wantReasons, "\n") {
+				t.Errorf("ECRValidator.Validate() reasons =\n%s\nwant\n%s", strings.Join(reasons, "\n"), strings.Join(tt.wantReasons, "\n"))
+			}
+		})
+	}
+}
+
+func TestECRValidatorValidateNotECR(t *testing.T) {
+	file, diags := ParseModules([]byte(rdsFile), "rds.tf")
+	if diags.HasErrors() {
+		t.Fatalf("ParseModules() diags = %v", diags)
+	}
+	if _, err := NewECRValidator().Validate(context.Background(), file.Module("rds"), nil, nil); err == nil {
+		t.Errorf("ECRValidator.Validate() expected an error for an rds module")
+	}
+}
+
+func TestECRValidatorRepoName(t *testing.T) {
+	tests := []struct {
+		name      string
+		repoName  string
+		namespace string
+		opts      []ECRValidatorOptions
+		wantMatch bool
+	}{
+		{"same name", "my-app-dev", "my-app-dev", nil, true},
+		{"environment suffix", "my-app", "my-app-preprod", nil, true},
+		{"other suffix", "my-app", "my-app-feature", nil, false},
+		{"one word prefix", "hmpps", "hmpps-tier-dev", nil, false},
+		{"custom suffix", "my-app", "my-app-uat", []ECRValidatorOptions{WithEnvironmentSuffixes("-uat")}, true},
+		{"prefixes allowed", "my-app", "my-app-feature", []ECRValidatorOptions{WithRepoNamePrefixes()}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewECRValidator(tt.opts...).repoNameMatches(tt.repoName, tt.namespace); got != tt.wantMatch {
+				t.Errorf("ECRValidator.repoNameMatches(%q, %q) = %v, want %v", tt.repoName, tt.namespace, got, tt.wantMatch)
+			}
+		})
+	}
+}
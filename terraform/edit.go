@@ -0,0 +1,331 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FileEditor changes the module blocks of a terraform file, keeping its comments and
+// the layout of everything it doesn't change. Use ParseModules and File.ModulesBySource
+// to find the modules to change, e.g. to upgrade a module in every namespace, and
+// github.CreateChangePullRequest to raise the result.
+type FileEditor struct {
+	filename string
+	file     *hclwrite.File
+}
+
+// NewFileEditor parses terraform source for editing. The filename is only used in diagnostics.
+// On failure the error wraps the hcl.Diagnostics, which can be retrieved with errors.As.
+func NewFileEditor(content []byte, filename string) (*FileEditor, error) {
+	file, diags := hclwrite.ParseConfig(content, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing HCL file: %w", diags)
+	}
+	return &FileEditor{filename: filename, file: file}, nil
+}
+
+// Bytes returns the edited file. Only the values set are formatted, so the rest of the file
+// is left exactly as it was, and new attributes aren't aligned with their neighbours.
+func (e *FileEditor) Bytes() []byte {
+	// File.Bytes formats the whole file, so the tokens are written out directly.
+	var buf bytes.Buffer
+	_, _ = e.file.BuildTokens(nil).WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// SetSourceRef changes the ref of a module's source, e.g. to upgrade
+// github.com/org/repo?ref=6.1.0 to 6.2.0, adding a ref if it has none. The rest of
+// the source is left as it is. For a registry module, its version is set instead. Other
+// sources, such as local paths, can't be pinned and are an error.
+func (e *FileEditor) SetSourceRef(module, ref string) error {
+	block, err := e.module(module)
+	if err != nil {
+		return err
+	}
+
+	source, err := literalString(block.Body().GetAttribute("source"))
+	if err != nil {
+		return fmt.Errorf("error reading source of module %q: %w", module, err)
+	}
+
+	parsed, err := ParseSource(source)
+	if err != nil {
+		return err
+	}
+	switch parsed.Kind {
+	case SourceRegistry:
+		return setAttributeValue(block, "version", cty.StringVal(ref))
+	case SourceGit:
+		return setAttributeValue(block, "source", cty.StringVal(withRef(source, ref)))
+	default:
+		return fmt.Errorf("source of module %q is not a git or registry source, so has no ref: %s", module, source)
+	}
+}
+
+// SetAttribute sets an attribute of a module to a value, adding it at the end of the
+// block if it isn't set already.
+func (e *FileEditor) SetAttribute(module, name string, value cty.Value) error {
+	block, err := e.module(module)
+	if err != nil {
+		return err
+	}
+	return setAttributeValue(block, name, value)
+}
+
+// SetAttributeExpr sets an attribute of a module to an expression, e.g. "var.namespace",
+// adding it at the end of the block if it isn't set already.
+func (e *FileEditor) SetAttributeExpr(module, name, expr string) error {
+	block, err := e.module(module)
+	if err != nil {
+		return err
+	}
+	tokens, err := expressionTokens([]byte(expr))
+	if err != nil {
+		return err
+	}
+	setAttributeTokens(block, name, tokens)
+	return nil
+}
+
+// AddTag sets a key in the object of a module's tags argument, creating the argument if
+// the module doesn't have one. The other keys of the object, and comments in it, are kept.
+// It is an error for tags to be anything other than an object, e.g. merge(local.tags, {}).
+// Cloud Platform modules that take each tag as an argument should use SetAttribute.
+func (e *FileEditor) AddTag(module, key, value string) error {
+	block, err := e.module(module)
+	if err != nil {
+		return err
+	}
+
+	attr := block.Body().GetAttribute("tags")
+	if attr == nil {
+		return setAttributeValue(block, "tags", cty.ObjectVal(map[string]cty.Value{key: cty.StringVal(value)}))
+	}
+
+	src := attr.Expr().BuildTokens(nil).Bytes()
+	expr, diags := hclsyntax.ParseExpression(src, e.filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("error parsing tags of module %q: %w", module, diags)
+	}
+	object, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return fmt.Errorf("tags of module %q is not an object", module)
+	}
+
+	quoted := quotedString(value)
+	var edited []byte
+	for _, item := range object.Items {
+		if objectKey(item.KeyExpr) != key {
+			continue
+		}
+		r := item.ValueExpr.Range()
+		edited = append(append(append(edited, src[:r.Start.Byte]...), quoted...), src[r.End.Byte:]...)
+		break
+	}
+	if edited == nil {
+		// The new key goes on a line of its own before the closing brace.
+		open, end := object.OpenRange.End.Byte, object.SrcRange.End.Byte
+		items := src[open : end-1]
+		edited = append(edited, src[:open]...)
+		if bytes.Contains(items, []byte("\n")) {
+			items = bytes.TrimRight(items, " \t")
+		} else {
+			// An object written on one line, e.g. { a = "b" }, is split over several.
+			items = bytes.TrimSpace(items)
+			edited = append(edited, '\n')
+		}
+		edited = append(edited, items...)
+		if len(items) > 0 && !bytes.HasSuffix(items, []byte("\n")) {
+			edited = append(edited, '\n')
+		}
+		edited = append(edited, fmt.Sprintf("%s = %s\n}", tokenKey(key), quoted)...)
+		edited = append(edited, src[end:]...)
+	}
+
+	tokens, err := expressionTokens(edited)
+	if err != nil {
+		return err
+	}
+	setAttributeTokens(block, "tags", tokens)
+	return nil
+}
+
+// module returns the module block with the label name.
+func (e *FileEditor) module(name string) (*hclwrite.Block, error) {
+	for _, block := range e.file.Body().Blocks() {
+		if block.Type() == "module" && len(block.Labels()) == 1 && block.Labels()[0] == name {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("module %q not found in %s", name, e.filename)
+}
+
+// setAttributeValue sets an attribute of a module block to a value, formatted as
+// expressionTokens formats it.
+func setAttributeValue(block *hclwrite.Block, name string, value cty.Value) error {
+	tokens, err := expressionTokens(hclwrite.TokensForValue(value).Bytes())
+	if err != nil {
+		return err
+	}
+	setAttributeTokens(block, name, tokens)
+	return nil
+}
+
+// setAttributeTokens sets an attribute of a module block to the tokens of an expression from
+// expressionTokens. As the file isn't formatted as a whole, a new attribute is indented like
+// the module's source. A block on one line, e.g. module "x" { source = "y" }, can only hold
+// one attribute, so it is first split over several lines.
+func setAttributeTokens(block *hclwrite.Block, name string, tokens hclwrite.Tokens) {
+	body := block.Body()
+	if body.GetAttribute(name) != nil {
+		body.SetAttributeRaw(name, tokens)
+		return
+	}
+
+	blockTokens := block.BuildTokens(nil)
+	if open, end := blockBraces(blockTokens); !slices.ContainsFunc(blockTokens[open:end], isNewline) {
+		blockIndent := 0
+		for _, token := range blockTokens {
+			if token.Type == hclsyntax.TokenIdent {
+				blockIndent = token.SpacesBefore
+				break
+			}
+		}
+		// The block holds at most one attribute, which is added back on a line of its own.
+		attrs := make(map[string]hclwrite.Tokens)
+		for attrName, attr := range body.Attributes() {
+			attrs[attrName] = attr.Expr().BuildTokens(nil)
+			body.RemoveAttribute(attrName)
+		}
+		body.AppendNewline()
+		for attrName, attrTokens := range attrs {
+			appendAttributeTokens(body, attrName, attrTokens, blockIndent+2)
+		}
+		blockTokens[end].SpacesBefore = blockIndent
+	}
+
+	indent := 2
+	if source := body.GetAttribute("source"); source != nil {
+		indent = source.BuildTokens(nil)[0].SpacesBefore
+	}
+	appendAttributeTokens(body, name, tokens, indent)
+}
+
+// appendAttributeTokens adds a new attribute to the end of a body, indented by indent spaces.
+func appendAttributeTokens(body *hclwrite.Body, name string, tokens hclwrite.Tokens, indent int) {
+	// SetAttributeRaw returns nil for a new attribute, so it is looked up again. BuildTokens
+	// returns the attribute's own tokens, so they can be spaced in place.
+	body.SetAttributeRaw(name, tokens)
+	attrTokens := body.GetAttribute(name).BuildTokens(nil)
+	attrTokens[0].SpacesBefore = indent
+	attrTokens[1].SpacesBefore = 1
+	attrTokens[2].SpacesBefore = 1
+}
+
+// blockBraces returns the indexes of a block's opening and closing braces in its tokens.
+// Labels can't hold braces, so the first opening brace is the block's, and the closing brace
+// is the last, as only a newline follows it.
+func blockBraces(tokens hclwrite.Tokens) (int, int) {
+	open := slices.IndexFunc(tokens, func(token *hclwrite.Token) bool {
+		return token.Type == hclsyntax.TokenOBrace
+	})
+	for end := len(tokens) - 1; end > open; end-- {
+		if tokens[end].Type == hclsyntax.TokenCBrace {
+			return open, end
+		}
+	}
+	return open, len(tokens)
+}
+
+// isNewline reports whether a token is a newline.
+func isNewline(token *hclwrite.Token) bool {
+	return token.Type == hclsyntax.TokenNewline
+}
+
+// literalString returns the value of an attribute set to a literal string.
+func literalString(attr *hclwrite.Attribute) (string, error) {
+	if attr == nil {
+		return "", fmt.Errorf("attribute is not set")
+	}
+	expr, diags := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+		return "", fmt.Errorf("attribute is not a literal string")
+	}
+	return value.AsString(), nil
+}
+
+// withRef sets the ref query parameter of a source, keeping its other parameters in order.
+func withRef(source, ref string) string {
+	address, query, _ := strings.Cut(source, "?")
+	params := strings.Split(query, "&")
+	if query == "" {
+		params = nil
+	}
+
+	found := false
+	for i, param := range params {
+		if name, _, _ := strings.Cut(param, "="); name == "ref" {
+			params[i] = "ref=" + ref
+			found = true
+		}
+	}
+	if !found {
+		params = append(params, "ref="+ref)
+	}
+	return address + "?" + strings.Join(params, "&")
+}
+
+// objectKey returns the name of an object key, whether written as an identifier or a string.
+func objectKey(expr hclsyntax.Expression) string {
+	if keyword := hcl.ExprAsKeyword(expr); keyword != "" {
+		return keyword
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+		return ""
+	}
+	return value.AsString()
+}
+
+// tokenKey writes an object key as an identifier if it is one and quoted otherwise.
+func tokenKey(key string) string {
+	if hclsyntax.ValidIdentifier(key) {
+		return key
+	}
+	return quotedString(key)
+}
+
+// quotedString writes a string as an HCL string literal, escaping any template sequences.
+func quotedString(s string) string {
+	return string(hclwrite.TokensForValue(cty.StringVal(s)).Bytes())
+}
+
+// expressionTokens parses an expression and formats it as the value of a module argument,
+// so nested lines are indented to match.
+func expressionTokens(expr []byte) (hclwrite.Tokens, error) {
+	src := append(append([]byte("module \"m\" {\n  x = "), expr...), "\n}\n"...)
+	file, diags := hclwrite.ParseConfig(hclwrite.Format(src), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing expression %q: %w", expr, diags)
+	}
+	blocks := file.Body().Blocks()
+	if len(blocks) != 1 {
+		return nil, fmt.Errorf("error parsing expression %q", expr)
+	}
+	attr := blocks[0].Body().GetAttribute("x")
+	if attr == nil {
+		return nil, fmt.Errorf("error parsing expression %q", expr)
+	}
+	return attr.Expr().BuildTokens(nil), nil
+}
@@ -0,0 +1,154 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const editFile = `# Container registry for the application
+module "ecr" {
+  source    = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0&depth=1" # pinned
+  repo_name = "my-app"
+
+  # Tags from the namespace
+  tags = {
+    business_unit = "HQ"
+    # the team that owns it
+    team_name = "my-team"
+  }
+}
+
+module "iam" {
+  source  = "terraform-aws-modules/iam/aws"
+  version = "5.30.0"
+  tags    = { team_name = "my-team" }
+}
+
+module "sqs" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-sqs"
+  tags   = merge(local.tags, {})
+  # terraform fmt would change these, but they aren't edited
+  subnets = [
+      "a",
+         "b"]
+}
+
+module "app" {
+  source = "./modules/app"
+}
+
+module "one" { source = "./modules/one" }
+
+module "tagged" {source="./modules/tagged"}
+`
+
+const editedFile = `# Container registry for the application
+module "ecr" {
+  source    = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.2.0&depth=1" # pinned
+  repo_name = "my-app"
+
+  # Tags from the namespace
+  tags = {
+    business_unit = "OCTO"
+    # the team that owns it
+    team_name   = "my-team"
+    application = "My $${app}"
+  }
+  namespace = var.namespace
+  enable_irsa = true
+}
+
+module "iam" {
+  source  = "terraform-aws-modules/iam/aws"
+  version = "5.31.0"
+  tags    = {
+    team_name     = "my-team"
+    business_unit = "OCTO"
+  }
+}
+
+module "sqs" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=5.0.0"
+  tags   = merge(local.tags, {})
+  # terraform fmt would change these, but they aren't edited
+  subnets = [
+      "a",
+         "b"]
+}
+
+module "app" {
+  source = "./modules/app"
+}
+
+module "one" {
+  source = "./modules/one"
+  namespace = "x"
+}
+
+module "tagged" {
+  source = "./modules/tagged"
+  tags = {
+    team_name = "my-team"
+  }
+}
+`
+
+func TestFileEditor(t *testing.T) {
+	editor, err := NewFileEditor([]byte(editFile), "ecr.tf")
+	if err != nil {
+		t.Fatalf("NewFileEditor() error = %v", err)
+	}
+
+	for _, edit := range []struct {
+		name string
+		err  error
+	}{
+		{"SetSourceRef(ecr)", editor.SetSourceRef("ecr", "6.2.0")},
+		{"SetSourceRef(iam)", editor.SetSourceRef("iam", "5.31.0")},
+		{"SetSourceRef(sqs)", editor.SetSourceRef("sqs", "5.0.0")},
+		{"AddTag(business_unit)", editor.AddTag("ecr", "business_unit", "OCTO")},
+		{"AddTag(application)", editor.AddTag("ecr", "application", "My ${app}")},
+		{"AddTag(iam)", editor.AddTag("iam", "business_unit", "OCTO")},
+		{"SetAttributeExpr(namespace)", editor.SetAttributeExpr("ecr", "namespace", "var.namespace")},
+		{"SetAttribute(enable_irsa)", editor.SetAttribute("ecr", "enable_irsa", cty.True)},
+		{"SetAttribute(one)", editor.SetAttribute("one", "namespace", cty.StringVal("x"))},
+		{"AddTag(tagged)", editor.AddTag("tagged", "team_name", "my-team")},
+	} {
+		if edit.err != nil {
+			t.Errorf("FileEditor.%s error = %v", edit.name, edit.err)
+		}
+	}
+
+	// Only the values set are formatted; new attributes aren't aligned with their neighbours.
+	got, want := string(editor.Bytes()), editedFile
+	if file, diags := ParseModules([]byte(got), "ecr.tf"); diags.HasErrors() || file.Module("ecr").Ref != "6.2.0" {
+		t.Fatalf("FileEditor.Bytes() isn't valid terraform: %v\n%s", diags, got)
+	}
+	if got != want {
+		t.Errorf("FileEditor.Bytes() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFileEditorErrors(t *testing.T) {
+	editor, err := NewFileEditor([]byte(editFile), "ecr.tf")
+	if err != nil {
+		t.Fatalf("NewFileEditor() error = %v", err)
+	}
+
+	if err := editor.SetSourceRef("missing", "1.0.0"); err == nil {
+		t.Errorf("FileEditor.SetSourceRef() expected an error for a missing module")
+	}
+	if err := editor.SetSourceRef("app", "1.0.0"); err == nil {
+		t.Errorf("FileEditor.SetSourceRef() expected an error for a local source")
+	}
+	if err := editor.AddTag("sqs", "team_name", "my-team"); err == nil {
+		t.Errorf("FileEditor.AddTag() expected an error for tags that aren't an object")
+	}
+	if err := editor.SetAttributeExpr("ecr", "namespace", "var."); err == nil {
+		t.Errorf("FileEditor.SetAttributeExpr() expected an error for an invalid expression")
+	}
+	if _, err := NewFileEditor([]byte(`module "broken" {`), "broken.tf"); err == nil {
+		t.Errorf("NewFileEditor() expected an error for invalid HCL")
+	}
+}
@@ -0,0 +1,191 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// functions are the terraform functions expressions can call when they are evaluated.
+// They are the ones commonly used to build module arguments; an expression calling any
+// other function is left unresolved.
+var functions = map[string]function.Function{
+	"coalesce":  stdlib.CoalesceFunc,
+	"concat":    stdlib.ConcatFunc,
+	"format":    stdlib.FormatFunc,
+	"join":      stdlib.JoinFunc,
+	"length":    stdlib.LengthFunc,
+	"lookup":    stdlib.LookupFunc,
+	"lower":     stdlib.LowerFunc,
+	"merge":     stdlib.MergeFunc,
+	"replace":   stdlib.ReplaceFunc,
+	"split":     stdlib.SplitFunc,
+	"tobool":    stdlib.MakeToFunc(cty.Bool),
+	"tonumber":  stdlib.MakeToFunc(cty.Number),
+	"tostring":  stdlib.MakeToFunc(cty.String),
+	"trimspace": stdlib.TrimSpaceFunc,
+	"upper":     stdlib.UpperFunc,
+}
+
+// LoadEvalContext reads the .tf and .tfvars files in a directory, such as a namespace's
+// resources directory, and returns an EvalContext for evaluating module arguments in it.
+func LoadEvalContext(dir string) (*hcl.EvalContext, hcl.Diagnostics) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to read directory",
+			Detail:   err.Error(),
+		}}
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".tf") && !strings.HasSuffix(name, ".tfvars")) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read file",
+				Detail:   err.Error(),
+				Subject:  &hcl.Range{Filename: path},
+			}}
+		}
+		files[path] = content
+	}
+
+	return NewEvalContext(files)
+}
+
+// NewEvalContext builds an EvalContext from the content of a directory's terraform files,
+// keyed by filename, e.g. files fetched from GitHub with github.GetFileAtRef.
+//
+// var is set from the defaults of the variable blocks in the .tf files, overridden by
+// terraform.tfvars, then *.auto.tfvars and then any other .tfvars files, each in
+// lexical order. A variable with no value is left out rather than made unknown, so
+// expressions using it are left unresolved. local is set from the locals blocks, as far
+// as they can be evaluated from var and each other.
+func NewEvalContext(files map[string][]byte) (*hcl.EvalContext, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	variables := make(map[string]cty.Value)
+	var locals []*hclsyntax.Attribute
+
+	var tfvars []string
+	for _, name := range sortedKeys(files) {
+		if strings.HasSuffix(name, ".tfvars") {
+			tfvars = append(tfvars, name)
+			continue
+		}
+		if !strings.HasSuffix(name, ".tf") {
+			continue
+		}
+
+		file, fileDiags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+		diags = append(diags, fileDiags...)
+		if fileDiags.HasErrors() {
+			continue
+		}
+
+		for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+			switch {
+			case block.Type == "variable" && len(block.Labels) == 1:
+				def, ok := block.Body.Attributes["default"]
+				if !ok {
+					continue
+				}
+				if value, valueDiags := def.Expr.Value(nil); !valueDiags.HasErrors() {
+					variables[block.Labels[0]] = value
+				}
+			case block.Type == "locals":
+				for _, attr := range block.Body.Attributes {
+					locals = append(locals, attr)
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(tfvars, func(a, b string) int {
+		return tfvarsPrecedence(a) - tfvarsPrecedence(b)
+	})
+	for _, name := range tfvars {
+		file, fileDiags := hclsyntax.ParseConfig(files[name], name, hcl.InitialPos)
+		diags = append(diags, fileDiags...)
+		if fileDiags.HasErrors() {
+			continue
+		}
+		attrs, attrDiags := file.Body.JustAttributes()
+		diags = append(diags, attrDiags...)
+		for name, attr := range attrs {
+			value, valueDiags := attr.Expr.Value(nil)
+			diags = append(diags, valueDiags...)
+			if !valueDiags.HasErrors() {
+				variables[name] = value
+			}
+		}
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":   cty.ObjectVal(variables),
+			"local": cty.EmptyObjectVal,
+		},
+		Functions: functions,
+	}
+
+	// Locals can refer to each other in any order, so they are evaluated in passes
+	// until a pass resolves nothing new.
+	values := make(map[string]cty.Value)
+	for len(locals) > 0 {
+		var pending []*hclsyntax.Attribute
+		for _, attr := range locals {
+			value, valueDiags := attr.Expr.Value(ctx)
+			if valueDiags.HasErrors() || !value.IsWhollyKnown() {
+				pending = append(pending, attr)
+				continue
+			}
+			values[attr.Name] = value
+		}
+		if len(pending) == len(locals) {
+			break
+		}
+		ctx.Variables["local"] = cty.ObjectVal(values)
+		locals = pending
+	}
+
+	return ctx, diags
+}
+
+// tfvarsPrecedence orders tfvars files so that later files override earlier ones,
+// as terraform does: terraform.tfvars, then *.auto.tfvars, then the rest.
+func tfvarsPrecedence(name string) int {
+	base := filepath.Base(name)
+	switch {
+	case base == "terraform.tfvars":
+		return 0
+	case strings.HasSuffix(base, ".auto.tfvars"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// sortedKeys returns the keys of a map in lexical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -4,31 +4,28 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/hcl/v2"
-	gohcl "github.com/hashicorp/hcl/v2/gohcl"
 	hclparse "github.com/hashicorp/hcl/v2/hclparse"
-	structs "github.com/ministryofjustice/cloud-platform-go-library/structs"
 )
 
+// GetTFBody parses the HCL file at the given path and returns its body.
+// On failure the error wraps the hcl.Diagnostics, which can be retrieved with errors.As.
 func GetTFBody(source string) (hcl.Body, error) {
 	parser := hclparse.NewParser()
 	file, diags := parser.ParseHCLFile(source)
 	if diags.HasErrors() {
-		return nil, fmt.Errorf("error parsing HCL file")
+		return nil, fmt.Errorf("error parsing HCL file: %w", diags)
 	}
 	return file.Body, nil
 }
 
-func MapTfFileToStruct(source string, body hcl.Body) (interface{}, error) {
-	switch source {
-	case "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials":
-		var ecr structs.ECR
-		diags := gohcl.DecodeBody(body, nil, &ecr)
-		if diags.HasErrors() {
-			fmt.Println("error decoding HCL file")
-			return nil, fmt.Errorf("error decoding HCL file")
-		}
-		return ecr, nil
-	default:
-		return nil, fmt.Errorf("module not found")
+// GetTFBodyFromBytes parses HCL held in memory, such as the content of a file fetched
+// with github.DecodeContent, and returns its body. The filename is only used in diagnostics.
+// On failure the error wraps the hcl.Diagnostics, which can be retrieved with errors.As.
+func GetTFBodyFromBytes(content []byte, filename string) (hcl.Body, error) {
+	parser := hclparse.NewParser()
+	file, diags := parser.ParseHCL(content, filename)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing HCL file: %w", diags)
 	}
+	return file.Body, nil
 }
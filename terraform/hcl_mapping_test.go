@@ -0,0 +1,54 @@
+package terraform
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestGetTFBodyFromBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantErr  bool
+		wantLine int
+	}{
+		{
+			name:    "valid hcl",
+			content: "repo_name = \"my-app\"\n",
+		},
+		{
+			name:     "invalid hcl",
+			content:  "repo_name = \"my-app\"\noidc_providers = [\n",
+			wantErr:  true,
+			wantLine: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := GetTFBodyFromBytes([]byte(tt.content), "namespaces/live/c/ns/resources/ecr.tf")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTFBodyFromBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if body == nil {
+					t.Error("GetTFBodyFromBytes() body = nil")
+				}
+				return
+			}
+
+			var diags hcl.Diagnostics
+			if !errors.As(err, &diags) {
+				t.Fatalf("GetTFBodyFromBytes() error %v doesn't wrap hcl.Diagnostics", err)
+			}
+			if got := diags[0].Subject; got.Filename != "namespaces/live/c/ns/resources/ecr.tf" || got.Start.Line < tt.wantLine {
+				t.Errorf("GetTFBodyFromBytes() diag at %v, want ecr.tf from line %d", got, tt.wantLine)
+			}
+			if !strings.Contains(err.Error(), "ecr.tf") {
+				t.Errorf("GetTFBodyFromBytes() error %q should include the diagnostic detail", err)
+			}
+		})
+	}
+}
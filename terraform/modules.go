@@ -0,0 +1,250 @@
+package terraform
+
+import (
+	"os"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// File is a parsed terraform file and the module blocks in it.
+type File struct {
+	Filename string
+	// Bytes is the source the file was parsed from.
+	Bytes   []byte
+	Body    *hclsyntax.Body
+	Modules []*Module
+}
+
+// Module is a module block in a terraform file.
+type Module struct {
+	// Name is the block label, e.g. "ecr_credentials" in module "ecr_credentials" {}.
+	Name string
+	// Source is the value of the source attribute.
+	Source string
+	// Version is the value of the version attribute, used by registry modules.
+	Version string
+	// Ref is the ref query parameter of a git source, e.g. "6.1.0" in
+	// github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0
+	Ref string
+	// Attributes are every attribute of the block, including source and version.
+	Attributes map[string]*Attribute
+	// Range covers the whole block and DefRange its header, e.g. module "ecr" {
+	Range    hcl.Range
+	DefRange hcl.Range
+	// Body is the block's body, for decoding into a typed struct.
+	Body *hclsyntax.Body
+}
+
+// Attribute is an attribute of a module block.
+type Attribute struct {
+	Name string
+	Expr hcl.Expression
+	// Value is the value of the expression when it can be worked out without any
+	// variables, e.g. a literal string, list or object; otherwise it is cty.NilVal.
+	Value cty.Value
+	// Raw is the expression's source text, e.g. var.namespace.
+	Raw   string
+	Range hcl.Range
+}
+
+// Known reports whether the attribute's value could be worked out.
+func (a *Attribute) Known() bool {
+	return a.Value != cty.NilVal && a.Value.IsWhollyKnown()
+}
+
+// ParseModulesFile reads and parses a terraform file from disk.
+func ParseModulesFile(path string) (*File, hcl.Diagnostics) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to read file",
+			Detail:   err.Error(),
+			Subject:  &hcl.Range{Filename: path},
+		}}
+	}
+	return ParseModules(content, path)
+}
+
+// ParseModules parses terraform source, such as the decoded content of a file fetched from
+// GitHub, and returns every module block in it. The filename is only used in diagnostics.
+// Diagnostics are returned for syntax errors and for module blocks without a literal source;
+// the file is still returned alongside warnings.
+func ParseModules(content []byte, filename string) (*File, hcl.Diagnostics) {
+	hclFile, diags := hclsyntax.ParseConfig(content, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	body := hclFile.Body.(*hclsyntax.Body)
+	file := &File{
+		Filename: filename,
+		Bytes:    content,
+		Body:     body,
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type != "module" {
+			continue
+		}
+		module, moduleDiags := newModule(block, content)
+		diags = append(diags, moduleDiags...)
+		if module != nil {
+			file.Modules = append(file.Modules, module)
+		}
+	}
+
+	return file, diags
+}
+
+// ModulesBySource returns the modules whose source, ignoring any ref, is the given source.
+// Sources are compared by their canonical address, so github.com/org/repo matches
+// git::https://github.com/org/repo.git.
+func (f *File) ModulesBySource(source string) []*Module {
+	address := sourceAddress(source)
+	var modules []*Module
+	for _, m := range f.Modules {
+		if sourceAddress(m.Source) == address {
+			modules = append(modules, m)
+		}
+	}
+	return modules
+}
+
+// Module returns the module with the given block label, or nil.
+func (f *File) Module(name string) *Module {
+	i := slices.IndexFunc(f.Modules, func(m *Module) bool { return m.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return f.Modules[i]
+}
+
+// SourceLocation is the source of a module block and where it is set.
+type SourceLocation struct {
+	// Module is the block label.
+	Module string
+	// Source is the source's value, or its expression text if it isn't a literal string.
+	Source  string
+	Literal bool
+	// Range is the range of the source's value and DefRange that of the block header.
+	Range    hcl.Range
+	DefRange hcl.Range
+}
+
+// Sources returns the source of every module block with a source attribute, in the
+// order of the blocks.
+func (f *File) Sources() []SourceLocation {
+	sources := make([]SourceLocation, 0, len(f.Modules))
+	for _, m := range f.Modules {
+		attr, ok := m.Attributes["source"]
+		if !ok {
+			continue
+		}
+		location := SourceLocation{
+			Module:   m.Name,
+			Source:   attr.Raw,
+			Range:    attr.Expr.Range(),
+			DefRange: m.DefRange,
+		}
+		if m.Source != "" {
+			location.Source = m.Source
+			location.Literal = true
+		}
+		sources = append(sources, location)
+	}
+	return sources
+}
+
+// ExtractModuleSources parses terraform source and returns the source of every module
+// block in it, with the block's label and where the source is set. Comments, other blocks
+// and attributes such as data_source are ignored. Sources that aren't literal strings are
+// returned as their expression text, alongside a diagnostic.
+func ExtractModuleSources(content []byte, filename string) ([]SourceLocation, hcl.Diagnostics) {
+	file, diags := ParseModules(content, filename)
+	if file == nil {
+		return nil, diags
+	}
+	return file.Sources(), diags
+}
+
+// ParseSource parses the module's source.
+func (m *Module) ParseSource() (*ModuleSource, error) {
+	return ParseSource(m.Source)
+}
+
+// newModule builds a Module from a module block.
+func newModule(block *hclsyntax.Block, content []byte) (*Module, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	if len(block.Labels) != 1 {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid module block",
+			Detail:   "A module block must have exactly one label, its name.",
+			Subject:  block.DefRange().Ptr(),
+		}}
+	}
+
+	module := &Module{
+		Name:       block.Labels[0],
+		Attributes: make(map[string]*Attribute, len(block.Body.Attributes)),
+		Range:      block.Range(),
+		DefRange:   block.DefRange(),
+		Body:       block.Body,
+	}
+
+	for name, attr := range block.Body.Attributes {
+		module.Attributes[name] = newAttribute(attr, content)
+	}
+
+	source, ok := module.Attributes["source"]
+	switch {
+	case !ok:
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing module source",
+			Detail:   "Module \"" + module.Name + "\" has no source attribute.",
+			Subject:  block.DefRange().Ptr(),
+		})
+	case !source.Known() || source.Value.Type() != cty.String:
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid module source",
+			Detail:   "The source of module \"" + module.Name + "\" must be a literal string.",
+			Subject:  source.Range.Ptr(),
+		})
+	default:
+		module.Source = source.Value.AsString()
+		if parsed, err := ParseSource(module.Source); err == nil {
+			module.Ref = parsed.Ref
+		}
+	}
+
+	if version, ok := module.Attributes["version"]; ok && version.Known() && version.Value.Type() == cty.String {
+		module.Version = version.Value.AsString()
+	}
+
+	return module, diags
+}
+
+// newAttribute builds an Attribute, working out its value if it needs no variables.
+func newAttribute(attr *hclsyntax.Attribute, content []byte) *Attribute {
+	a := &Attribute{
+		Name:  attr.Name,
+		Expr:  attr.Expr,
+		Raw:   string(attr.Expr.Range().SliceBytes(content)),
+		Range: attr.SrcRange,
+	}
+
+	if len(attr.Expr.Variables()) == 0 {
+		if value, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+			a.Value = value
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,151 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+const ecrFile = `# ECR for the application
+module "ecr_credentials" {
+  source    = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"
+  repo_name = "my-app"
+  namespace = var.namespace
+
+  oidc_providers      = ["github"]
+  github_repositories = ["my-app"]
+}
+
+data "aws_caller_identity" "current" {}
+
+module "rds" {
+  source  = "ministryofjustice/rds-instance/aws"
+  version = "7.0.0"
+}
+`
+
+func TestParseModules(t *testing.T) {
+	file, diags := ParseModules([]byte(ecrFile), "resources/ecr.tf")
+	if diags.HasErrors() {
+		t.Fatalf("ParseModules() diags = %v", diags)
+	}
+	if len(file.Modules) != 2 {
+		t.Fatalf("ParseModules() found %d modules, want 2", len(file.Modules))
+	}
+
+	ecr := file.Module("ecr_credentials")
+	if ecr == nil {
+		t.Fatal("ParseModules() didn't find the ecr_credentials module")
+	}
+	if ecr.Source != "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0" || ecr.Ref != "6.1.0" {
+		t.Errorf("ecr source = %q ref = %q", ecr.Source, ecr.Ref)
+	}
+	if ecr.DefRange.Start.Line != 2 || ecr.Range.End.Line != 9 {
+		t.Errorf("ecr range = %v, want lines 2 to 9", ecr.Range)
+	}
+	if got := ecr.Attributes["repo_name"]; !got.Known() || got.Value != cty.StringVal("my-app") {
+		t.Errorf("repo_name = %#v, want a known value of my-app", got.Value)
+	}
+	if got := ecr.Attributes["namespace"]; got.Known() || got.Raw != "var.namespace" {
+		t.Errorf("namespace = known %v raw %q, want unknown var.namespace", got.Known(), got.Raw)
+	}
+	if got := ecr.Attributes["oidc_providers"]; !got.Known() || got.Value.LengthInt() != 1 {
+		t.Errorf("oidc_providers = %#v, want a known list", got.Value)
+	}
+
+	rds := file.Module("rds")
+	if rds.Version != "7.0.0" || rds.Ref != "" {
+		t.Errorf("rds version = %q ref = %q, want 7.0.0 and no ref", rds.Version, rds.Ref)
+	}
+
+	if got := file.ModulesBySource("github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials"); len(got) != 1 {
+		t.Errorf("ModulesBySource() = %v, want the ecr module", got)
+	}
+}
+
+func TestParseModulesDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantLine int
+	}{
+		{name: "syntax error", content: "module \"ecr\" {\n  source = \n}\n", wantLine: 2},
+		{name: "missing source", content: "\nmodule \"ecr\" {\n  repo_name = \"a\"\n}\n", wantLine: 2},
+		{name: "non literal source", content: "module \"ecr\" {\n  source = var.source\n}\n", wantLine: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := ParseModules([]byte(tt.content), "main.tf")
+			if !diags.HasErrors() {
+				t.Fatal("ParseModules() expected errors")
+			}
+			if got := diags[0].Subject; got.Filename != "main.tf" || got.Start.Line != tt.wantLine {
+				t.Errorf("ParseModules() diag at %v, want main.tf line %d", got, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestParseModulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ecr.tf")
+	if err := os.WriteFile(path, []byte(ecrFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, diags := ParseModulesFile(path)
+	if diags.HasErrors() || len(file.Modules) != 2 {
+		t.Errorf("ParseModulesFile() = %v, %v", file, diags)
+	}
+
+	if _, diags := ParseModulesFile(filepath.Join(t.TempDir(), "missing.tf")); !diags.HasErrors() {
+		t.Errorf("ParseModulesFile() expected an error for a missing file")
+	}
+}
+
+func TestExtractModuleSources(t *testing.T) {
+	content := `# source = "github.com/example/commented-out"
+data "external" "thing" {
+  data_source = "not-a-module"
+}
+
+module "ecr" {
+  # the ref is pinned
+  source = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0&depth=1"
+}
+
+module "dynamic" {
+  source = var.module_source
+}
+
+module "sqs" {
+  source = "git::https://github.com/ministryofjustice/cloud-platform-terraform-sqs.git?ref=5.0.0"
+}
+`
+	sources, diags := ExtractModuleSources([]byte(content), "main.tf")
+	if len(diags) != 1 {
+		t.Errorf("ExtractModuleSources() diags = %v, want one for the dynamic source", diags)
+	}
+
+	want := []SourceLocation{
+		{Module: "ecr", Source: "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0&depth=1", Literal: true},
+		{Module: "dynamic", Source: "var.module_source"},
+		{Module: "sqs", Source: "git::https://github.com/ministryofjustice/cloud-platform-terraform-sqs.git?ref=5.0.0", Literal: true},
+	}
+	if len(sources) != len(want) {
+		t.Fatalf("ExtractModuleSources() = %+v, want %d sources", sources, len(want))
+	}
+	for i, w := range want {
+		got := sources[i]
+		if got.Module != w.Module || got.Source != w.Source || got.Literal != w.Literal {
+			t.Errorf("ExtractModuleSources()[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if r := sources[0].Range; r.Start.Line != 8 || r.Start.Column != 12 {
+		t.Errorf("ExtractModuleSources()[0].Range = %v, want line 8 column 12", r)
+	}
+	if r := sources[2].DefRange; r.Start.Line != 15 {
+		t.Errorf("ExtractModuleSources()[2].DefRange = %v, want line 15", r)
+	}
+}
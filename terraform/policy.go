@@ -0,0 +1,298 @@
+package terraform
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// ModuleRule approves or denies a module.
+type ModuleRule struct {
+	// Source is the module without a ref, in any form ParseSource understands, e.g.
+	// github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials, or org/repo
+	// for a GitHub repository. A rule for a repository also covers its sub-paths, unless
+	// a sub-path has a rule of its own.
+	Source string `json:"source" yaml:"source"`
+	// Versions is the range the module's version must be in, as comma separated
+	// constraints, e.g. ">= 6.0.0, < 8.0.0" or "~> 6.1". Empty allows any ref.
+	Versions string `json:"versions,omitempty" yaml:"versions,omitempty"`
+	// Deny rejects the module whatever its version.
+	Deny bool `json:"deny,omitempty" yaml:"deny,omitempty"`
+	// Reason is reported with a violation of the rule, e.g. "use rds-aurora instead".
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// PolicyOptions configure a Policy.
+type PolicyOptions func(*Policy)
+
+// WithLocalModules allows modules with a local path source, e.g. ../modules/app,
+// which are otherwise violations.
+func WithLocalModules() PolicyOptions {
+	return func(p *Policy) {
+		p.allowLocal = true
+	}
+}
+
+// Policy checks module sources against an allowlist of ModuleRules. A module without
+// a rule is a violation.
+type Policy struct {
+	rules      map[string]*policyRule
+	allowLocal bool
+}
+
+type policyRule struct {
+	ModuleRule
+	constraints VersionConstraints
+}
+
+// Violation is a module that breaks a Policy.
+type Violation struct {
+	// Module is the block label of the module, and empty when a source was checked on its own.
+	Module string
+	Source string
+	Reason string
+	// Range is the range of the module's source attribute.
+	Range hcl.Range
+}
+
+// Error returns the violation as a sentence.
+func (v Violation) Error() string {
+	if v.Module == "" {
+		return v.Reason
+	}
+	return fmt.Sprintf("module %q: %s", v.Module, v.Reason)
+}
+
+// Violations are every violation in a file, in the order of their modules.
+type Violations []Violation
+
+// Diagnostics returns an error diagnostic for each violation, e.g. for
+// github.DiagnosticAnnotations.
+func (vs Violations) Diagnostics() hcl.Diagnostics {
+	diags := make(hcl.Diagnostics, 0, len(vs))
+	for _, v := range vs {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Module not approved",
+			Detail:   v.Error(),
+			Subject:  v.Range.Ptr(),
+		})
+	}
+	return diags
+}
+
+// NewPolicy compiles the rules into a Policy. It is an error for a rule to have a source
+// that can't be parsed, an invalid version range or the same source as another rule.
+func NewPolicy(rules []ModuleRule, opts ...PolicyOptions) (*Policy, error) {
+	p := &Policy{rules: make(map[string]*policyRule, len(rules))}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for _, rule := range rules {
+		source := rule.Source
+		if first, _, _ := strings.Cut(source, "/"); strings.Count(source, "/") == 1 && !strings.Contains(first, ".") {
+			source = "github.com/" + source
+		}
+		parsed, err := ParseSource(source)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing rule: %w", err)
+		}
+		if parsed.Ref != "" {
+			return nil, fmt.Errorf("rule for %q has a ref; use versions to restrict it", rule.Source)
+		}
+
+		address := parsed.Address()
+		if _, ok := p.rules[address]; ok {
+			return nil, fmt.Errorf("more than one rule for %q", address)
+		}
+
+		constraints, err := ParseVersionConstraints(rule.Versions)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing versions of rule for %q: %w", rule.Source, err)
+		}
+		p.rules[address] = &policyRule{ModuleRule: rule, constraints: constraints}
+	}
+
+	return p, nil
+}
+
+// CheckSource checks a module source, including its ref, and returns the violation or nil.
+func (p *Policy) CheckSource(source string) *Violation {
+	return p.check(source, "")
+}
+
+// CheckModule checks a module block. The version of a registry module is read from its
+// version attribute, which must then be an exact version if its rule has a range.
+func (p *Policy) CheckModule(module *Module) *Violation {
+	v := p.check(module.Source, module.Version)
+	if v == nil {
+		return nil
+	}
+	v.Module = module.Name
+	if source, ok := module.Attributes["source"]; ok {
+		v.Range = source.Range
+	} else {
+		v.Range = module.DefRange
+	}
+	return v
+}
+
+// CheckFile checks every module in a file.
+func (p *Policy) CheckFile(file *File) Violations {
+	var violations Violations
+	for _, module := range file.Modules {
+		if v := p.CheckModule(module); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	return violations
+}
+
+// check checks a source, with version being the version attribute of a registry module.
+func (p *Policy) check(source, version string) *Violation {
+	violation := func(format string, args ...interface{}) *Violation {
+		return &Violation{Source: source, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	parsed, err := ParseSource(source)
+	if err != nil {
+		return violation("%v", err)
+	}
+	if parsed.Kind == SourceLocal {
+		if p.allowLocal {
+			return nil
+		}
+		return violation("local module %q is not allowed", source)
+	}
+
+	rule, ok := p.rules[parsed.Address()]
+	if !ok && parsed.SubPath != "" {
+		repository := *parsed
+		repository.SubPath = ""
+		rule, ok = p.rules[repository.Address()]
+	}
+	if !ok {
+		return violation("module source %q is not approved", parsed.Address())
+	}
+
+	// ruleViolation adds the rule's reason, if it has one, to a violation of it.
+	ruleViolation := func(format string, args ...interface{}) *Violation {
+		v := violation(format, args...)
+		if rule.Reason != "" {
+			v.Reason += ": " + rule.Reason
+		}
+		return v
+	}
+
+	if rule.Deny {
+		return ruleViolation("module source %q is denied", parsed.Address())
+	}
+	if len(rule.constraints) == 0 {
+		return nil
+	}
+
+	ref := parsed.Ref
+	if parsed.Kind == SourceRegistry {
+		ref = version
+	}
+	if ref == "" {
+		return ruleViolation("module %q must be pinned to a version in %q", parsed.Address(), rule.Versions)
+	}
+	v, err := ParseVersion(ref)
+	if err != nil {
+		return ruleViolation("module %q is pinned to %q, which is not a version in %q", parsed.Address(), ref, rule.Versions)
+	}
+	if !rule.constraints.Check(v) {
+		return ruleViolation("module %q version %s is not in %q", parsed.Address(), ref, rule.Versions)
+	}
+
+	return nil
+}
+
+// VersionConstraints are constraints a version must meet all of.
+type VersionConstraints []VersionConstraint
+
+// VersionConstraint is a comparison with a version, as in terraform version constraints.
+type VersionConstraint struct {
+	// Operator is one of =, !=, >, >=, <, <= or ~>.
+	Operator string
+	Version  Version
+	// parts is how many of major, minor and patch were written, for ~>.
+	parts int
+}
+
+// versionOperators are the constraint operators, longest first so that a prefix
+// match finds e.g. ">=" before ">".
+var versionOperators = []string{">=", "<=", "!=", "~>", ">", "<", "="}
+
+// ParseVersionConstraints parses comma separated constraints, e.g. ">= 6.0.0, < 8.0.0".
+// A version without an operator means "=". "~> 6.1" allows 6.1 and above, below 7.0,
+// and "~> 6.1.0" allows 6.1.0 and above, below 6.2.0. An empty string has no constraints.
+func ParseVersionConstraints(s string) (VersionConstraints, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var constraints VersionConstraints
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		c := VersionConstraint{Operator: "="}
+		if i := slices.IndexFunc(versionOperators, func(op string) bool { return strings.HasPrefix(term, op) }); i >= 0 {
+			c.Operator = versionOperators[i]
+			term = strings.TrimSpace(strings.TrimPrefix(term, c.Operator))
+		}
+
+		v, err := ParseVersion(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.Version = v
+		core, _, _ := strings.Cut(term, "-")
+		core, _, _ = strings.Cut(core, "+")
+		c.parts = strings.Count(core, ".") + 1
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+// Check reports whether v meets every constraint.
+func (cs VersionConstraints) Check(v Version) bool {
+	for _, c := range cs {
+		if !c.Check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check reports whether v meets the constraint.
+func (c VersionConstraint) Check(v Version) bool {
+	cmp := v.Compare(c.Version)
+	switch c.Operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		if cmp < 0 {
+			return false
+		}
+		upper := Version{Major: c.Version.Major + 1}
+		if c.parts == 3 {
+			upper = Version{Major: c.Version.Major, Minor: c.Version.Minor + 1}
+		}
+		return v.LessThan(upper)
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"strings"
+	"testing"
+)
+
+var testRules = []ModuleRule{
+	{Source: "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials", Versions: ">= 6.0.0, < 8.0.0"},
+	{Source: "ministryofjustice/cloud-platform-terraform-sqs", Versions: "~> 5.1"},
+	{Source: "ministryofjustice/cloud-platform-terraform-rds-aurora", Deny: true, Reason: "use cloud-platform-terraform-rds-instance"},
+	{Source: "ministryofjustice/cloud-platform-terraform-irsa"},
+	{Source: "terraform-aws-modules/iam/aws", Versions: "~> 5.30.0"},
+}
+
+func TestPolicyCheckSource(t *testing.T) {
+	policy, err := NewPolicy(testRules)
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		source     string
+		wantReason string
+	}{
+		{name: "approved", source: "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"},
+		{name: "approved git form", source: "git::https://github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials.git?ref=7.9.9"},
+		{name: "approved sub-path", source: "github.com/ministryofjustice/cloud-platform-terraform-irsa//modules/role?ref=main"},
+		{name: "pessimistic", source: "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=5.9.0"},
+		{name: "fork", source: "github.com/evil/cloud-platform-terraform-ecr-credentials-fork?ref=6.1.0", wantReason: "not approved"},
+		{name: "same repo name in another org", source: "github.com/evil/cloud-platform-terraform-ecr-credentials?ref=6.1.0", wantReason: "not approved"},
+		{name: "version too high", source: "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=8.0.0", wantReason: "not in"},
+		{name: "pessimistic too high", source: "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=6.0.0", wantReason: "not in"},
+		{name: "branch", source: "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=main", wantReason: "not a version"},
+		{name: "unpinned", source: "github.com/ministryofjustice/cloud-platform-terraform-sqs", wantReason: "must be pinned"},
+		{name: "denied", source: "github.com/ministryofjustice/cloud-platform-terraform-rds-aurora?ref=1.0.0", wantReason: "denied: use cloud-platform-terraform-rds-instance"},
+		{name: "local", source: "../modules/app", wantReason: "local module"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := policy.CheckSource(tt.source)
+			if (v != nil) != (tt.wantReason != "") {
+				t.Fatalf("Policy.CheckSource() = %v, want reason %q", v, tt.wantReason)
+			}
+			if v != nil && !strings.Contains(v.Reason, tt.wantReason) {
+				t.Errorf("Policy.CheckSource() reason = %q, want it to contain %q", v.Reason, tt.wantReason)
+			}
+		})
+	}
+
+	local, err := NewPolicy(nil, WithLocalModules())
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	if v := local.CheckSource("./modules/app"); v != nil {
+		t.Errorf("Policy.CheckSource() with local modules = %v", v)
+	}
+}
+
+func TestPolicyCheckFile(t *testing.T) {
+	policy, err := NewPolicy(testRules)
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	content := `module "ecr" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=5.0.0"
+}
+
+module "iam" {
+  source  = "terraform-aws-modules/iam/aws"
+  version = "5.30.2"
+}
+
+module "aurora" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-rds-aurora?ref=3.0.0"
+}
+
+module "unknown" {
+  source = "github.com/example/unknown?ref=1.0.0"
+}
+`
+	file, diags := ParseModules([]byte(content), "main.tf")
+	if diags.HasErrors() {
+		t.Fatalf("ParseModules() diags = %v", diags)
+	}
+
+	violations := policy.CheckFile(file)
+	var modules []string
+	for _, v := range violations {
+		modules = append(modules, v.Module)
+	}
+	if got := strings.Join(modules, ","); got != "ecr,aurora,unknown" {
+		t.Fatalf("Policy.CheckFile() violations for %s, want ecr,aurora,unknown", got)
+	}
+	if violations[0].Range.Start.Line != 2 {
+		t.Errorf("Violation.Range = %v, want the source attribute on line 2", violations[0].Range)
+	}
+	if diags := violations.Diagnostics(); len(diags) != 3 || !strings.HasPrefix(diags[1].Detail, `module "aurora": `) {
+		t.Errorf("Violations.Diagnostics() = %v", diags)
+	}
+}
+
+func TestNewPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []ModuleRule
+	}{
+		{name: "bare repo name", rules: []ModuleRule{{Source: "cloud-platform-terraform-sqs"}}},
+		{name: "ref", rules: []ModuleRule{{Source: "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=1.0.0"}}},
+		{name: "invalid versions", rules: []ModuleRule{{Source: "ministryofjustice/cloud-platform-terraform-sqs", Versions: ">= main"}}},
+		{name: "duplicate", rules: []ModuleRule{
+			{Source: "ministryofjustice/cloud-platform-terraform-sqs"},
+			{Source: "github.com/ministryofjustice/cloud-platform-terraform-sqs"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy(tt.rules); err == nil {
+				t.Errorf("NewPolicy() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	structs "github.com/ministryofjustice/cloud-platform-go-library/structs"
+)
+
+// cloudPlatformSource is the prefix of every Cloud Platform module source.
+const cloudPlatformSource = "github.com/ministryofjustice/cloud-platform-terraform-"
+
+// ErrModuleNotFound is returned when decoding a module whose source has no registered struct.
+var ErrModuleNotFound = errors.New("module not found")
+
+var (
+	registryMu sync.RWMutex
+	// registry maps a module source, without a ref, to a function returning
+	// a pointer to a new struct to decode the module block into.
+	registry = map[string]func() interface{}{
+		cloudPlatformSource + "ecr-credentials":     func() interface{} { return &structs.ECR{} },
+		cloudPlatformSource + "rds-instance":        func() interface{} { return &structs.RDS{} },
+		cloudPlatformSource + "elasticache-cluster": func() interface{} { return &structs.ElastiCache{} },
+		cloudPlatformSource + "s3-bucket":           func() interface{} { return &structs.S3Bucket{} },
+		cloudPlatformSource + "sqs":                 func() interface{} { return &structs.SQS{} },
+		cloudPlatformSource + "sns-topic":           func() interface{} { return &structs.SNS{} },
+		cloudPlatformSource + "dynamodb-cluster":    func() interface{} { return &structs.DynamoDB{} },
+		cloudPlatformSource + "opensearch":          func() interface{} { return &structs.OpenSearch{} },
+		cloudPlatformSource + "irsa":                func() interface{} { return &structs.IRSA{} },
+		cloudPlatformSource + "service-pod":         func() interface{} { return &structs.ServicePod{} },
+		cloudPlatformSource + "serviceaccount":      func() interface{} { return &structs.ServiceAccount{} },
+	}
+)
+
+// RegisterModule adds, or replaces, the struct a module source is decoded into, so
+// new modules can be supported without changing this package. The source is matched by
+// its canonical address, without any ref, and newStruct must return a pointer to a struct
+// with hcl tags.
+func RegisterModule(source string, newStruct func() interface{}) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[sourceAddress(source)] = newStruct
+}
+
+// RegisteredSources returns the module sources that have a registered struct.
+func RegisteredSources() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	sources := make([]string, 0, len(registry))
+	for source := range registry {
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// newModuleStruct returns a pointer to a new struct for the module source.
+func newModuleStruct(source string) (interface{}, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	newStruct, ok := registry[sourceAddress(source)]
+	if !ok {
+		return nil, false
+	}
+	return newStruct(), true
+}
+
+// MapTfFileToStruct decodes the body of a module block into the struct registered for
+// its source, e.g. structs.ECR for the ecr-credentials module, and returns the struct by value.
+// Any ref on the source is ignored, and the source may be in any form ParseSource
+// understands. Attributes the struct has no field for are ignored, and fields whose
+// expressions reference variables are left empty; use DecodeModuleWithContext to
+// evaluate them.
+func MapTfFileToStruct(source string, body hcl.Body) (interface{}, error) {
+	target, ok := newModuleStruct(source)
+	if !ok {
+		return nil, ErrModuleNotFound
+	}
+
+	_, _, diags := decodeBody(body, nil, target)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error decoding HCL file: %w", diags)
+	}
+
+	return reflect.ValueOf(target).Elem().Interface(), nil
+}
+
+// DecodeModule decodes a parsed module block into the struct registered for its source,
+// without evaluating variables, as MapTfFileToStruct does.
+func DecodeModule(module *Module) (interface{}, error) {
+	decoded, err := DecodeModuleWithContext(module, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decoded.Value, nil
+}
@@ -0,0 +1,89 @@
+package terraform
+
+import (
+	"testing"
+
+	structs "github.com/ministryofjustice/cloud-platform-go-library/structs"
+)
+
+const rdsFile = `module "rds" {
+  source               = "github.com/ministryofjustice/cloud-platform-terraform-rds-instance?ref=8.0.0"
+  db_engine            = "postgres"
+  db_engine_version    = "16"
+  db_allocated_storage = 10
+  deletion_protection  = true
+  business_unit        = "HQ"
+  namespace            = "my-ns"
+}
+
+module "queue" {
+  source   = "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=5.0.0"
+  sqs_name = "jobs"
+}
+
+module "custom" {
+  source = "github.com/example/custom-module?ref=1.0.0"
+  name   = "custom"
+}
+`
+
+type customModule struct {
+	Source string `hcl:"source"`
+	Name   string `hcl:"name"`
+}
+
+// registerTestModule registers a module struct until the end of the test, restoring any
+// struct registered for the source before.
+func registerTestModule(t *testing.T, source string, newStruct func() interface{}) {
+	t.Helper()
+	registryMu.RLock()
+	previous, ok := registry[sourceAddress(source)]
+	registryMu.RUnlock()
+
+	RegisterModule(source, newStruct)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		if ok {
+			registry[sourceAddress(source)] = previous
+		} else {
+			delete(registry, sourceAddress(source))
+		}
+	})
+}
+
+func TestDecodeModule(t *testing.T) {
+	file, diags := ParseModules([]byte(rdsFile), "rds.tf")
+	if diags.HasErrors() {
+		t.Fatalf("ParseModules() diags = %v", diags)
+	}
+
+	got, err := DecodeModule(file.Module("rds"))
+	if err != nil {
+		t.Fatalf("DecodeModule(rds) error = %v", err)
+	}
+	rds, ok := got.(structs.RDS)
+	if !ok {
+		t.Fatalf("DecodeModule(rds) = %T, want structs.RDS", got)
+	}
+	if rds.DBEngine != "postgres" || rds.DBAllocatedStorage != "10" || rds.DeletionProtection == nil || !*rds.DeletionProtection {
+		t.Errorf("DecodeModule(rds) = %+v", rds)
+	}
+	if tags := rds.ModuleTags(); tags.Business_unit != "HQ" || tags.Namespace != "my-ns" {
+		t.Errorf("RDS.ModuleTags() = %+v", tags)
+	}
+
+	if got, err := DecodeModule(file.Module("queue")); err != nil || got.(structs.SQS).SQSName != "jobs" {
+		t.Errorf("DecodeModule(queue) = %v, %v", got, err)
+	}
+
+	if _, err := DecodeModule(file.Module("custom")); err == nil {
+		t.Errorf("DecodeModule(custom) expected an error before the module is registered")
+	}
+
+	registerTestModule(t, "github.com/example/custom-module", func() interface{} { return &customModule{} })
+	got, err = DecodeModule(file.Module("custom"))
+	if err != nil || got.(customModule).Name != "custom" {
+		t.Errorf("DecodeModule(custom) = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,342 @@
+package terraform
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SourceKind is the kind of location a module source points to.
+type SourceKind string
+
+const (
+	// SourceGit is a git repository, either in the github.com/org/repo shorthand
+	// or the git:: forced form, e.g. git::https://github.com/org/repo.git.
+	SourceGit SourceKind = "git"
+	// SourceRegistry is a module registry address, e.g. terraform-aws-modules/vpc/aws
+	// or app.terraform.io/org/vpc/aws.
+	SourceRegistry SourceKind = "registry"
+	// SourceLocal is a path relative to the calling module, e.g. ../modules/app.
+	SourceLocal SourceKind = "local"
+	// SourceOther is any other source, such as an archive URL or an s3:: source.
+	SourceOther SourceKind = "other"
+)
+
+// defaultRegistryHost is the host of registry sources without one.
+const defaultRegistryHost = "registry.terraform.io"
+
+// ModuleSource is a parsed module source.
+type ModuleSource struct {
+	// Raw is the source as written.
+	Raw  string
+	Kind SourceKind
+	// Host is e.g. "github.com", or the registry host of a registry source.
+	Host string
+	// Org and Repo are the owner and repository of a git source, without any .git
+	// suffix, or the namespace and module name of a registry source.
+	Org  string
+	Repo string
+	// Provider is the target system of a registry source, e.g. "aws".
+	Provider string
+	// SubPath is the directory within the repository or package, after a "//".
+	SubPath string
+	// Ref is the ref query parameter of a git source, e.g. "6.1.0".
+	Ref string
+}
+
+// ParseSource parses a module source. GitHub sources are recognised in each of the forms
+// terraform accepts: github.com/org/repo, git@github.com:org/repo.git and the git:: forms
+// of an https or ssh URL. Sources it can't split into a host, org and repo are returned
+// as SourceOther, with Host set where there is one.
+func ParseSource(source string) (*ModuleSource, error) {
+	if source == "" {
+		return nil, fmt.Errorf("module source is empty")
+	}
+
+	s := &ModuleSource{Raw: source}
+
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		s.Kind = SourceLocal
+		s.SubPath = source
+		return s, nil
+	}
+
+	address, query, _ := strings.Cut(source, "?")
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("module source %q has an invalid query: %w", source, err)
+		}
+		s.Ref = values.Get("ref")
+	}
+
+	getter, rest, forced := strings.Cut(address, "::")
+	if !forced {
+		rest = address
+		getter = ""
+	}
+
+	switch {
+	case getter == "git":
+		return s, s.parseGitURL(rest)
+	case getter != "":
+		s.Kind = SourceOther
+		s.Host = urlHost(rest)
+		return s, nil
+	case strings.HasPrefix(rest, "git@"):
+		// git@github.com:org/repo.git is scp-like shorthand for an ssh URL.
+		host, path, ok := strings.Cut(strings.TrimPrefix(rest, "git@"), ":")
+		if !ok {
+			return nil, fmt.Errorf("module source %q is not in the form git@host:org/repo", source)
+		}
+		return s, s.parseRepoPath(host, path)
+	case strings.HasPrefix(rest, "github.com/"), strings.HasPrefix(rest, "bitbucket.org/"):
+		host, path, _ := strings.Cut(rest, "/")
+		return s, s.parseRepoPath(host, path)
+	case strings.Contains(rest, "://"):
+		s.Kind = SourceOther
+		s.Host = urlHost(rest)
+		return s, nil
+	}
+
+	return s, s.parseRegistry(rest)
+}
+
+// parseGitURL parses the URL of a git:: source, e.g. https://github.com/org/repo.git//sub.
+func (s *ModuleSource) parseGitURL(rawURL string) error {
+	scheme, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return fmt.Errorf("module source %q is not a git URL", s.Raw)
+	}
+	switch scheme {
+	case "https", "http", "ssh":
+	default:
+		return fmt.Errorf("module source %q has an unsupported git URL scheme %q", s.Raw, scheme)
+	}
+
+	host, path, _ := strings.Cut(rest, "/")
+	if _, after, ok := strings.Cut(host, "@"); ok {
+		host = after
+	}
+	host, _, _ = strings.Cut(host, ":")
+	return s.parseRepoPath(host, path)
+}
+
+// parseRepoPath sets the git host, org, repo and sub-path from a path like org/repo.git//sub.
+func (s *ModuleSource) parseRepoPath(host, path string) error {
+	path, s.SubPath, _ = strings.Cut(path, "//")
+	org, repo, ok := strings.Cut(path, "/")
+	repo = strings.TrimSuffix(repo, ".git")
+	if !ok || host == "" || org == "" || repo == "" || strings.Contains(repo, "/") {
+		return fmt.Errorf("module source %q is not in the form %s/org/repo", s.Raw, host)
+	}
+	s.Kind = SourceGit
+	s.Host = host
+	s.Org = org
+	s.Repo = repo
+	return nil
+}
+
+// parseRegistry parses a registry address, [host/]namespace/name/provider[//sub].
+func (s *ModuleSource) parseRegistry(address string) error {
+	address, s.SubPath, _ = strings.Cut(address, "//")
+	parts := strings.Split(address, "/")
+	switch {
+	case len(parts) == 3:
+		s.Host = defaultRegistryHost
+	case len(parts) == 4 && strings.Contains(parts[0], "."):
+		s.Host = parts[0]
+		parts = parts[1:]
+	default:
+		return fmt.Errorf("module source %q is not a git, registry or local source", s.Raw)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("module source %q is not a git, registry or local source", s.Raw)
+		}
+	}
+	s.Kind = SourceRegistry
+	s.Org = parts[0]
+	s.Repo = parts[1]
+	s.Provider = parts[2]
+	return nil
+}
+
+// urlHost returns the host of a URL, or "" if it has none.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// Address returns the source without its ref, in a canonical form, so that the same
+// module written in different forms has the same address. A GitHub repository's address
+// is always github.com/org/repo, followed by //sub-path if it has one.
+func (s *ModuleSource) Address() string {
+	var address string
+	switch s.Kind {
+	case SourceGit:
+		address = s.Host + "/" + s.Org + "/" + s.Repo
+	case SourceRegistry:
+		address = s.Org + "/" + s.Repo + "/" + s.Provider
+		if s.Host != defaultRegistryHost {
+			address = s.Host + "/" + address
+		}
+	default:
+		address, _, _ = strings.Cut(s.Raw, "?")
+		return address
+	}
+	if s.SubPath != "" {
+		address += "//" + s.SubPath
+	}
+	return address
+}
+
+// Repository returns org/repo, e.g. "ministryofjustice/cloud-platform-terraform-ecr-credentials".
+func (s *ModuleSource) Repository() string {
+	return s.Org + "/" + s.Repo
+}
+
+// Version parses the source's ref as a semantic version.
+func (s *ModuleSource) Version() (Version, error) {
+	if s.Ref == "" {
+		return Version{}, fmt.Errorf("module source %q has no ref", s.Raw)
+	}
+	return ParseVersion(s.Ref)
+}
+
+// sourceAddress returns the canonical address of a source, or the source without its
+// query string if it can't be parsed.
+func sourceAddress(source string) string {
+	s, err := ParseSource(source)
+	if err != nil {
+		address, _, _ := strings.Cut(source, "?")
+		return address
+	}
+	return s.Address()
+}
+
+// Version is a semantic version, e.g. the ref of a module source.
+type Version struct {
+	Major, Minor, Patch int
+	// Prerelease is e.g. "rc.1" in 1.0.0-rc.1.
+	Prerelease string
+	// Original is the version as written, e.g. "v6.1".
+	Original string
+}
+
+// ParseVersion parses a semantic version. A leading "v" is allowed, as are missing minor
+// and patch numbers, which are taken to be 0, since module tags are often written that way.
+// Build metadata after a "+" is ignored.
+func ParseVersion(s string) (Version, error) {
+	v := Version{Original: s}
+
+	rest := strings.TrimPrefix(s, "v")
+	rest, _, _ = strings.Cut(rest, "+")
+	rest, v.Prerelease, _ = strings.Cut(rest, "-")
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("version %q has more than three numbers", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part[0] == '+' {
+			return Version{}, fmt.Errorf("version %q is not a semantic version", s)
+		}
+		*numbers[i] = n
+	}
+
+	if strings.Contains(s, "-") && v.Prerelease == "" {
+		return Version{}, fmt.Errorf("version %q has an empty pre-release", s)
+	}
+
+	return v, nil
+}
+
+// String returns the version as major.minor.patch[-prerelease].
+func (v Version) String() string {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than o, following
+// semantic versioning precedence: a pre-release is lower than its release.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// LessThan reports whether v is lower than o.
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// CompareVersions parses and compares two versions, as Version.Compare.
+func CompareVersions(a, b string) (int, error) {
+	va, err := ParseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// comparePrerelease compares pre-release strings by their dot separated identifiers.
+// Numeric identifiers compare numerically and are lower than alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
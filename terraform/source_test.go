@@ -0,0 +1,203 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		want        *ModuleSource
+		wantAddress string
+		wantErr     bool
+	}{
+		{
+			name:   "github shorthand",
+			source: "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0",
+			want: &ModuleSource{
+				Kind: SourceGit,
+				Host: "github.com",
+				Org:  "ministryofjustice",
+				Repo: "cloud-platform-terraform-ecr-credentials",
+				Ref:  "6.1.0",
+			},
+			wantAddress: "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials",
+		},
+		{
+			name:   "github shorthand with sub-path",
+			source: "github.com/ministryofjustice/cloud-platform-terraform-rds-instance//modules/replica?ref=8.0.0",
+			want: &ModuleSource{
+				Kind:    SourceGit,
+				Host:    "github.com",
+				Org:     "ministryofjustice",
+				Repo:    "cloud-platform-terraform-rds-instance",
+				SubPath: "modules/replica",
+				Ref:     "8.0.0",
+			},
+			wantAddress: "github.com/ministryofjustice/cloud-platform-terraform-rds-instance//modules/replica",
+		},
+		{
+			name:   "git https",
+			source: "git::https://github.com/ministryofjustice/cloud-platform-terraform-sqs.git?ref=5.0.0",
+			want: &ModuleSource{
+				Kind: SourceGit,
+				Host: "github.com",
+				Org:  "ministryofjustice",
+				Repo: "cloud-platform-terraform-sqs",
+				Ref:  "5.0.0",
+			},
+			wantAddress: "github.com/ministryofjustice/cloud-platform-terraform-sqs",
+		},
+		{
+			name:   "git ssh",
+			source: "git::ssh://git@github.com/ministryofjustice/cloud-platform-terraform-sqs.git//sub?ref=v5",
+			want: &ModuleSource{
+				Kind:    SourceGit,
+				Host:    "github.com",
+				Org:     "ministryofjustice",
+				Repo:    "cloud-platform-terraform-sqs",
+				SubPath: "sub",
+				Ref:     "v5",
+			},
+			wantAddress: "github.com/ministryofjustice/cloud-platform-terraform-sqs//sub",
+		},
+		{
+			name:   "scp-like ssh",
+			source: "git@github.com:ministryofjustice/cloud-platform-terraform-sqs.git",
+			want: &ModuleSource{
+				Kind: SourceGit,
+				Host: "github.com",
+				Org:  "ministryofjustice",
+				Repo: "cloud-platform-terraform-sqs",
+			},
+			wantAddress: "github.com/ministryofjustice/cloud-platform-terraform-sqs",
+		},
+		{
+			name:   "public registry",
+			source: "terraform-aws-modules/iam/aws//modules/iam-assumable-role",
+			want: &ModuleSource{
+				Kind:     SourceRegistry,
+				Host:     "registry.terraform.io",
+				Org:      "terraform-aws-modules",
+				Repo:     "iam",
+				Provider: "aws",
+				SubPath:  "modules/iam-assumable-role",
+			},
+			wantAddress: "terraform-aws-modules/iam/aws//modules/iam-assumable-role",
+		},
+		{
+			name:   "private registry",
+			source: "app.terraform.io/example/vpc/aws",
+			want: &ModuleSource{
+				Kind:     SourceRegistry,
+				Host:     "app.terraform.io",
+				Org:      "example",
+				Repo:     "vpc",
+				Provider: "aws",
+			},
+			wantAddress: "app.terraform.io/example/vpc/aws",
+		},
+		{
+			name:        "local",
+			source:      "../modules/app",
+			want:        &ModuleSource{Kind: SourceLocal, SubPath: "../modules/app"},
+			wantAddress: "../modules/app",
+		},
+		{
+			name:        "archive",
+			source:      "https://example.com/module.zip?archive=zip",
+			want:        &ModuleSource{Kind: SourceOther, Host: "example.com"},
+			wantAddress: "https://example.com/module.zip",
+		},
+		{
+			name:    "empty",
+			source:  "",
+			wantErr: true,
+		},
+		{
+			name:    "github without a repo",
+			source:  "github.com/ministryofjustice",
+			wantErr: true,
+		},
+		{
+			name:    "unknown form",
+			source:  "cloud-platform-terraform-sqs",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSource(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			tt.want.Raw = tt.source
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSource() = %+v, want %+v", got, tt.want)
+			}
+			if address := got.Address(); address != tt.wantAddress {
+				t.Errorf("ModuleSource.Address() = %q, want %q", address, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "6.1.0", b: "6.1.0", want: 0},
+		{a: "v6.1", b: "6.1.0", want: 0},
+		{a: "6.1.0", b: "6.10.0", want: -1},
+		{a: "10.0.0", b: "9.9.9", want: 1},
+		{a: "1.0.0-rc.1", b: "1.0.0", want: -1},
+		{a: "1.0.0-rc.2", b: "1.0.0-rc.10", want: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-1", want: 1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{a: "1.0.0+build.1", b: "1.0.0", want: 0},
+		{a: "main", b: "1.0.0", wantErr: true},
+		{a: "1.0.0.0", b: "1.0.0", wantErr: true},
+		{a: "1.0.0-", b: "1.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			got, err := CompareVersions(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompareVersions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CompareVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModulesBySourceForms(t *testing.T) {
+	content := `module "a" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-sqs?ref=5.0.0"
+}
+
+module "b" {
+  source = "git::https://github.com/ministryofjustice/cloud-platform-terraform-sqs.git?ref=4.0.0"
+}
+
+module "c" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-sns-topic?ref=5.0.0"
+}
+`
+	file, diags := ParseModules([]byte(content), "main.tf")
+	if diags.HasErrors() {
+		t.Fatalf("ParseModules() diags = %v", diags)
+	}
+	got := file.ModulesBySource("git@github.com:ministryofjustice/cloud-platform-terraform-sqs.git")
+	if len(got) != 2 || got[1].Ref != "4.0.0" {
+		t.Errorf("ModulesBySource() = %v, want modules a and b", got)
+	}
+}
@@ -0,0 +1,71 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	structs "github.com/ministryofjustice/cloud-platform-go-library/structs"
+	v1 "k8s.io/api/core/v1"
+)
+
+// namespaceMetadataPrefix is the prefix of the Cloud Platform namespace annotations and labels.
+const namespaceMetadataPrefix = "cloud-platform.justice.gov.uk/"
+
+// TagMismatch is a module tag that disagrees with its namespace.
+type TagMismatch struct {
+	// Tag is the module argument, e.g. "business_unit".
+	Tag   string
+	Value string
+	// Namespace is the namespace's value, empty if it doesn't have one.
+	Namespace string
+	// Field is where the namespace's value is held, e.g.
+	// "annotation cloud-platform.justice.gov.uk/business-unit".
+	Field string
+}
+
+// Error returns the mismatch as a sentence.
+func (m TagMismatch) Error() string {
+	if m.Namespace == "" {
+		return fmt.Sprintf("%s is %q but the namespace has no %s", m.Tag, m.Value, m.Field)
+	}
+	return fmt.Sprintf("%s is %q but the namespace %s is %q", m.Tag, m.Value, m.Field, m.Namespace)
+}
+
+// CompareTags compares a module's tags with the annotations and labels of its namespace:
+//   - namespace with the namespace's name.
+//   - business_unit, application and team_name with the business-unit, application and
+//     team-name annotations.
+//   - is_production and environment_name with the is-production and environment-name labels.
+//   - infrastructure_support with the owner annotation, which should contain it, as the
+//     owner is written as "<team>: <email>".
+//
+// Tags that aren't set, e.g. because they reference a variable, aren't compared.
+func CompareTags(tags structs.Tags, ns *v1.Namespace) []TagMismatch {
+	var mismatches []TagMismatch
+	compare := func(tag, value, field, nsValue string, match func(string, string) bool) {
+		value = strings.TrimSpace(value)
+		if value == "" || match(value, nsValue) {
+			return
+		}
+		mismatches = append(mismatches, TagMismatch{Tag: tag, Value: value, Namespace: nsValue, Field: field})
+	}
+	equal := func(value, nsValue string) bool { return value == nsValue }
+	annotation := func(tag, value, name string, match func(string, string) bool) {
+		compare(tag, value, "annotation "+namespaceMetadataPrefix+name, ns.Annotations[namespaceMetadataPrefix+name], match)
+	}
+	label := func(tag, value, name string) {
+		compare(tag, value, "label "+namespaceMetadataPrefix+name, ns.Labels[namespaceMetadataPrefix+name], equal)
+	}
+
+	compare("namespace", tags.Namespace, "name", ns.Name, equal)
+	annotation("business_unit", tags.Business_unit, "business-unit", equal)
+	annotation("application", tags.Application, "application", equal)
+	annotation("team_name", tags.Team_name, "team-name", equal)
+	label("is_production", tags.Is_production, "is-production")
+	label("environment_name", tags.Environment_name, "environment-name")
+	annotation("infrastructure_support", tags.Infrastructure_support, "owner", func(value, owner string) bool {
+		return strings.Contains(owner, value)
+	})
+
+	return mismatches
+}
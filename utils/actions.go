@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// RefKind is the type of git ref a workflow ran on.
+type RefKind string
+
+const (
+	RefBranch      RefKind = "branch"
+	RefTag         RefKind = "tag"
+	RefPullRequest RefKind = "pull"
+)
+
+// Ref is a parsed GITHUB_REF, such as refs/heads/main or refs/pull/1/merge.
+type Ref struct {
+	Kind RefKind
+	// Name is the branch or tag name. For a pull request ref it is "head" or "merge".
+	Name string
+	// PullRequest is the pull request number of a pull request ref.
+	PullRequest int
+}
+
+// ActionsContext is the context a GitHub Actions workflow is running in, read from the
+// default environment variables and the event payload at GITHUB_EVENT_PATH.
+type ActionsContext struct {
+	// EventName is GITHUB_EVENT_NAME, e.g. "pull_request", "push" or "workflow_dispatch".
+	EventName string
+	Owner     string
+	Repo      string
+	// Ref is GITHUB_REF, or the zero Ref for an event without a branch or tag.
+	Ref   Ref
+	SHA   string
+	Actor string
+	// PullRequest is the pull request number for pull_request and pull_request_target events.
+	PullRequest int
+	// BaseRef and HeadRef are the branches of a pull request.
+	BaseRef string
+	HeadRef string
+	// HeadSHA is the head commit of a pull request, or the commit pushed to for a push event.
+	HeadSHA string
+	// Inputs are the inputs of a workflow_dispatch event.
+	Inputs map[string]string
+}
+
+// ReadActionsContext reads the context of the running GitHub Actions workflow from the environment.
+func ReadActionsContext() (*ActionsContext, error) {
+	return NewActionsContext(os.Getenv)
+}
+
+// NewActionsContext builds an ActionsContext using getenv to look up the environment variables.
+// The event payload is only parsed for pull_request, pull_request_target, push and
+// workflow_dispatch events; other events get the fields available from the environment.
+// GitHub doesn't set GITHUB_REF for events without a branch or tag, so it is only required
+// for the events whose payload is parsed, and other events without it get a zero Ref.
+func NewActionsContext(getenv func(string) string) (*ActionsContext, error) {
+	owner, repo, err := ParseRepository(getenv("GITHUB_REPOSITORY"))
+	if err != nil {
+		return nil, err
+	}
+
+	eventName := getenv("GITHUB_EVENT_NAME")
+	parsed := false
+	switch eventName {
+	case "pull_request", "pull_request_target", "push", "workflow_dispatch":
+		parsed = true
+	}
+
+	var ref Ref
+	if rawRef := getenv("GITHUB_REF"); parsed || rawRef != "" {
+		ref, err = ParseRef(rawRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ac := &ActionsContext{
+		EventName:   eventName,
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         ref,
+		SHA:         getenv("GITHUB_SHA"),
+		Actor:       getenv("GITHUB_ACTOR"),
+		PullRequest: ref.PullRequest,
+	}
+
+	if !parsed {
+		return ac, nil
+	}
+
+	path := getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return nil, fmt.Errorf("GITHUB_EVENT_PATH is not set for a %s event", ac.EventName)
+	}
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading event payload: %w", err)
+	}
+
+	if err := ac.parseEvent(payload); err != nil {
+		return nil, err
+	}
+
+	return ac, nil
+}
+
+// parseEvent fills in the context from an event payload.
+func (ac *ActionsContext) parseEvent(payload []byte) error {
+	switch ac.EventName {
+	case "pull_request", "pull_request_target":
+		var event github.PullRequestEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("error parsing %s event: %w", ac.EventName, err)
+		}
+		pull := event.GetPullRequest()
+		if pull == nil {
+			return fmt.Errorf("%s event has no pull_request", ac.EventName)
+		}
+		ac.PullRequest = pull.GetNumber()
+		ac.BaseRef = pull.GetBase().GetRef()
+		ac.HeadRef = pull.GetHead().GetRef()
+		ac.HeadSHA = pull.GetHead().GetSHA()
+	case "push":
+		var event github.PushEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("error parsing push event: %w", err)
+		}
+		ac.HeadSHA = event.GetAfter()
+	case "workflow_dispatch":
+		var event struct {
+			Inputs map[string]interface{} `json:"inputs"`
+		}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("error parsing workflow_dispatch event: %w", err)
+		}
+		// Inputs can be booleans or numbers in the payload, so they are all
+		// converted to strings as they would be in ${{ inputs.name }}.
+		ac.Inputs = make(map[string]string, len(event.Inputs))
+		for k, v := range event.Inputs {
+			ac.Inputs[k] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// ParseRef parses a fully qualified git ref into its kind, name and pull request number.
+func ParseRef(ref string) (Ref, error) {
+	switch {
+	case ref == "":
+		return Ref{}, fmt.Errorf("ref is empty")
+	case strings.HasPrefix(ref, "refs/heads/"):
+		name := strings.TrimPrefix(ref, "refs/heads/")
+		if name == "" {
+			return Ref{}, fmt.Errorf("ref %q has no branch name", ref)
+		}
+		return Ref{Kind: RefBranch, Name: name}, nil
+	case strings.HasPrefix(ref, "refs/tags/"):
+		name := strings.TrimPrefix(ref, "refs/tags/")
+		if name == "" {
+			return Ref{}, fmt.Errorf("ref %q has no tag name", ref)
+		}
+		return Ref{Kind: RefTag, Name: name}, nil
+	case strings.HasPrefix(ref, "refs/pull/"):
+		parts := strings.Split(strings.TrimPrefix(ref, "refs/pull/"), "/")
+		if len(parts) != 2 || (parts[1] != "merge" && parts[1] != "head") {
+			return Ref{}, fmt.Errorf("ref %q is not in the form refs/pull/<number>/merge or refs/pull/<number>/head", ref)
+		}
+		number, err := strconv.Atoi(parts[0])
+		if err != nil || number < 1 {
+			return Ref{}, fmt.Errorf("ref %q has an invalid pull request number %q", ref, parts[0])
+		}
+		return Ref{Kind: RefPullRequest, Name: parts[1], PullRequest: number}, nil
+	default:
+		return Ref{}, fmt.Errorf("ref %q is not a branch, tag or pull request ref", ref)
+	}
+}
+
+// ParseRepository splits a repository in the form owner/name, as in GITHUB_REPOSITORY.
+func ParseRepository(repository string) (string, string, error) {
+	owner, name, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("repository %q is not in the form owner/name", repository)
+	}
+	return owner, name, nil
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		want    Ref
+		wantErr bool
+	}{
+		{name: "pull request merge", ref: "refs/pull/12/merge", want: Ref{Kind: RefPullRequest, Name: "merge", PullRequest: 12}},
+		{name: "branch", ref: "refs/heads/main", want: Ref{Kind: RefBranch, Name: "main"}},
+		{name: "branch with slash", ref: "refs/heads/feature/ecr", want: Ref{Kind: RefBranch, Name: "feature/ecr"}},
+		{name: "tag", ref: "refs/tags/1.2.3", want: Ref{Kind: RefTag, Name: "1.2.3"}},
+		{name: "empty", ref: "", wantErr: true},
+		{name: "short ref", ref: "main", wantErr: true},
+		{name: "pull request without number", ref: "refs/pull/merge", wantErr: true},
+		{name: "pull request with invalid number", ref: "refs/pull/abc/merge", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRef() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOwnerRepoPullErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  string
+		repo string
+	}{
+		{name: "branch ref", ref: "refs/heads/main", repo: "ministryofjustice/cloud-platform-environments"},
+		{name: "repo without slash", ref: "refs/pull/1/merge", repo: "cloud-platform-environments"},
+		{name: "empty repo", ref: "refs/pull/1/merge", repo: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := GetOwnerRepoPull(tt.ref, tt.repo); err == nil {
+				t.Errorf("GetOwnerRepoPull() expected an error")
+			}
+		})
+	}
+}
+
+func TestNewActionsContext(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, payload string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(payload), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *ActionsContext
+		wantErr bool
+	}{
+		{
+			name: "pull request",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "pull_request",
+				"GITHUB_REPOSITORY": "ministryofjustice/cloud-platform-environments",
+				"GITHUB_REF":        "refs/pull/7/merge",
+				"GITHUB_EVENT_PATH": write("pr.json", `{"number":7,"pull_request":{"number":7,"base":{"ref":"main"},"head":{"ref":"ecr","sha":"abc"}}}`),
+			},
+			want: &ActionsContext{
+				EventName:   "pull_request",
+				Owner:       "ministryofjustice",
+				Repo:        "cloud-platform-environments",
+				Ref:         Ref{Kind: RefPullRequest, Name: "merge", PullRequest: 7},
+				PullRequest: 7,
+				BaseRef:     "main",
+				HeadRef:     "ecr",
+				HeadSHA:     "abc",
+			},
+		},
+		{
+			name: "push",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "push",
+				"GITHUB_REPOSITORY": "ministryofjustice/cloud-platform-environments",
+				"GITHUB_REF":        "refs/heads/main",
+				"GITHUB_EVENT_PATH": write("push.json", `{"ref":"refs/heads/main","after":"def"}`),
+			},
+			want: &ActionsContext{
+				EventName: "push",
+				Owner:     "ministryofjustice",
+				Repo:      "cloud-platform-environments",
+				Ref:       Ref{Kind: RefBranch, Name: "main"},
+				HeadSHA:   "def",
+			},
+		},
+		{
+			name: "workflow dispatch",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "workflow_dispatch",
+				"GITHUB_REPOSITORY": "ministryofjustice/cloud-platform-environments",
+				"GITHUB_REF":        "refs/heads/main",
+				"GITHUB_EVENT_PATH": write("dispatch.json", `{"inputs":{"namespace":"my-ns","dry_run":true}}`),
+			},
+			want: &ActionsContext{
+				EventName: "workflow_dispatch",
+				Owner:     "ministryofjustice",
+				Repo:      "cloud-platform-environments",
+				Ref:       Ref{Kind: RefBranch, Name: "main"},
+				Inputs:    map[string]string{"namespace": "my-ns", "dry_run": "true"},
+			},
+		},
+		{
+			name: "invalid payload",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "pull_request",
+				"GITHUB_REPOSITORY": "ministryofjustice/cloud-platform-environments",
+				"GITHUB_REF":        "refs/pull/7/merge",
+				"GITHUB_EVENT_PATH": write("invalid.json", `not json`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "event without a ref",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "schedule",
+				"GITHUB_REPOSITORY": "ministryofjustice/cloud-platform-environments",
+				"GITHUB_SHA":        "abc",
+			},
+			want: &ActionsContext{
+				EventName: "schedule",
+				Owner:     "ministryofjustice",
+				Repo:      "cloud-platform-environments",
+				SHA:       "abc",
+			},
+		},
+		{
+			name: "push without a ref",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "push",
+				"GITHUB_REPOSITORY": "ministryofjustice/cloud-platform-environments",
+				"GITHUB_EVENT_PATH": write("push-without-ref.json", `{}`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing event path",
+			env: map[string]string{
+				"GITHUB_EVENT_NAME": "push",
+				"GITHUB_REPOSITORY": "ministryofjustice/cloud-platform-environments",
+				"GITHUB_REF":        "refs/heads/main",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewActionsContext(func(key string) string { return tt.env[key] })
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewActionsContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewActionsContext() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
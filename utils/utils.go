@@ -2,44 +2,72 @@ package utils
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
+
+	"github.com/ministryofjustice/cloud-platform-go-library/terraform"
 )
 
+// GetOwnerRepoPull returns the owner, repository name and pull request number from a
+// pull request ref, such as refs/pull/1/merge, and a repository in the form owner/name.
+// Use ReadActionsContext to read the context of any event.
 func GetOwnerRepoPull(ref, repo string) (string, string, int, error) {
-
-	if ref == "" || repo == "" {
-		return "", "", 0, fmt.Errorf("ref is empty")
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if parsed.Kind != RefPullRequest {
+		return "", "", 0, fmt.Errorf("ref %q is not a pull request ref", ref)
 	}
 
-	githubrefS := strings.Split(ref, "/")
-	prnum := githubrefS[2]
-	pull, _ := strconv.Atoi(prnum)
-
-	repoS := strings.Split(repo, "/")
-	owner := repoS[0]
-	repoName := repoS[1]
+	owner, repoName, err := ParseRepository(repo)
+	if err != nil {
+		return "", "", 0, err
+	}
 
-	return owner, repoName, pull, nil
+	return owner, repoName, parsed.PullRequest, nil
 }
 
+// ValidateModuleSource reports whether a module source is approved. Each key of
+// approvedModules is a module source without a ref, approved if its value is true and
+// denied if it is false. The source is matched exactly by its org and repo, and may be
+// quoted and padded with spaces, as returned by GetSourceLine for a single source line.
+//
+// Deprecated: use terraform.NewPolicy, which also checks versions and reports every
+// violation in a file.
 func ValidateModuleSource(source string, approvedModules map[string]bool) (bool, error) {
-	for k, v := range approvedModules {
-		if strings.Contains(source, k) {
-			if v {
-				return true, nil
-			}
-		}
+	rules := make([]terraform.ModuleRule, 0, len(approvedModules))
+	for module, approved := range approvedModules {
+		rules = append(rules, terraform.ModuleRule{Source: module, Deny: !approved})
 	}
-	return false, fmt.Errorf("module not approved")
+
+	policy, err := terraform.NewPolicy(rules)
+	if err != nil {
+		return false, err
+	}
+
+	if v := policy.CheckSource(strings.Trim(strings.TrimSpace(source), `"`)); v != nil {
+		return false, fmt.Errorf("module not approved: %w", v)
+	}
+	return true, nil
 }
 
+// GetSourceLine returns the source of the first module block in terraform source. Input
+// without a module block, such as a lone source line, falls back to returning everything
+// after the "=" of the first line containing "source", as written, e.g. ` "org/repo"`.
+// It returns "" if neither finds a source.
+//
+// Deprecated: use terraform.ExtractModuleSources, which returns every module's source
+// with its label and position.
 func GetSourceLine(source string) string {
-	sourceS := strings.Split(source, "\n")
-	for _, line := range sourceS {
+	sources, _ := terraform.ExtractModuleSources([]byte(source), "")
+	if len(sources) > 0 {
+		return sources[0].Source
+	}
+
+	for _, line := range strings.Split(source, "\n") {
 		if strings.Contains(line, "source") {
-			sourceLine := strings.Split(line, "=")
-			return sourceLine[1]
+			_, value, _ := strings.Cut(line, "=")
+			return value
 		}
 	}
 	return ""
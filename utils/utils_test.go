@@ -97,6 +97,28 @@ func TestValidateModuleSource(t *testing.T) {
 			want:    false,
 			wantErr: true,
 		},
+		{
+			name: "Test ValidateModuleSource Fork Of Approved Module",
+			args: args{
+				source: "github.com/evil/cloud-platform-terraform-ecr-credentials-fork",
+				approvedModules: map[string]bool{
+					"github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials": true,
+				},
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "Test ValidateModuleSource Quoted Source With Ref",
+			args: args{
+				source: ` "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"`,
+				approvedModules: map[string]bool{
+					"github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials": true,
+				},
+			},
+			want:    true,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -111,3 +133,47 @@ func TestValidateModuleSource(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSourceLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name: "Test GetSourceLine Ignores Comments And Other Blocks",
+			source: `# the source = "commented-out"
+data "external" "thing" {
+  data_source = "not-a-module"
+}
+
+module "ecr" {
+  source = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"
+}
+`,
+			want: "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0",
+		},
+		{
+			name:   "Test GetSourceLine No Modules",
+			source: `locals {}`,
+			want:   "",
+		},
+		{
+			name:   "Test GetSourceLine Single Source Line",
+			source: `  source = "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"`,
+			want:   ` "github.com/ministryofjustice/cloud-platform-terraform-ecr-credentials?ref=6.1.0"`,
+		},
+		{
+			name:   "Test GetSourceLine Source Without Value",
+			source: `# source`,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetSourceLine(tt.source); got != tt.want {
+				t.Errorf("GetSourceLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}